@@ -3,14 +3,13 @@ package main
 import (
 	"Sber/app/internal/cache"
 	"Sber/app/internal/server"
-	"Sber/app/internal/task"
 	"Sber/app/pkg/config"
 	"Sber/app/pkg/logger"
 	postgres "Sber/app/pkg/storage"
 	"context"
 	"errors"
 	"flag"
-	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/julienschmidt/httprouter"
 	"golang.org/x/exp/slog"
 	"net/http"
@@ -18,6 +17,7 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 )
 
 // @title SberTask
@@ -26,24 +26,66 @@ func main() {
 	log := logger.GetLogger()
 	log.Info("Logger initialized")
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(log, os.Args[2:]); err != nil {
+			log.Fatal("migration failed: ", err)
+		}
+		return
+	}
+
 	configPath := flag.String("config-path", "config.yml", "path for application configuration file")
+	flag.Parse()
 	cfg := config.GetConfig(*configPath, ".env")
 	log.Info("Loaded config file")
 
-	dbConn, err := postgres.ConnectDB(*cfg)
-	if err != nil {
-		log.Error("cannot connect to database", err)
+	var dbPool, replicaPool *pgxpool.Pool
+	var err error
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	allCache := cache.New(cfg.Cache.MaxEntries, time.Duration(cfg.Cache.TTL)*time.Second)
+
+	if cfg.Cache.Policy != config.CacheWriteThrough && cfg.Cache.Policy != config.CacheWriteInvalidate {
+		log.Fatalf("unknown cache policy %q", cfg.Cache.Policy)
 	}
-	log.Info("Connected to database")
 
-	allCache := cache.NewCache()
-	if err = loadAllCache(log, dbConn, allCache); err != nil {
-		log.Error("Failed to preload caches:", err)
+	switch cfg.Storage.Type {
+	case config.StorageMemory:
+		log.Info("Using in-memory storage, the database is not used")
+		allCache.SetComplete(cfg.Cache.Policy == config.CacheWriteThrough)
+	case config.StoragePostgres:
+		if cfg.PostgreSQL.DSN == "" {
+			log.Fatal("DATABASE_DSN is required with the postgres storage")
+		}
+		dbPool, err = postgres.ConnectWithRetry(ctx, *cfg, log)
+		if err != nil {
+			log.Fatal("cannot connect to database: ", err)
+		}
+		log.Info("Connected to database")
+		go postgres.Watch(ctx, dbPool, time.Duration(cfg.PostgreSQL.HealthCheckPeriod)*time.Second, log)
+
+		replicaPool, err = postgres.ConnectReplica(*cfg)
+		if err != nil {
+			log.Error("cannot connect to read replica, list queries use the primary: ", err)
+		} else if replicaPool != nil {
+			log.Info("Connected to read replica")
+		}
+
+		if cfg.PostgreSQL.MigrateOnStart {
+			if err = applyMigrations(log, dbPool); err != nil {
+				log.Fatal("cannot apply database migrations: ", err)
+			}
+			log.Info("Database migrations applied")
+		}
+	default:
+		log.Fatalf("unknown storage type %q", cfg.Storage.Type)
 	}
-	log.Info("Records from the database are added to the cache")
 
-	numItems := len(allCache.Task)
-	log.Infof("Cache after loading data contains %d items", numItems)
+	var snapshotter *cache.Snapshotter
+	if cfg.Storage.Type == config.StoragePostgres && cfg.Cache.SnapshotPath != "" {
+		snapshotter = cache.NewSnapshotter(allCache, cfg.Cache.SnapshotPath, time.Duration(cfg.Cache.SnapshotInterval)*time.Second)
+		go snapshotter.Run(ctx)
+		log.Info("Started cache snapshots")
+	}
 
 	router := httprouter.New()
 	log.Info("Initialized httprouter")
@@ -56,38 +98,35 @@ func main() {
 	signal.Notify(quit, signals...)
 
 	go func() {
-		if err = srv.Run(dbConn); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		if err = srv.Run(dbPool, replicaPool); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Error("cannot run the server", err)
 		}
 	}()
 	log.Info("Server has been started ", slog.String("host", cfg.HTTP.Host), slog.String("port", cfg.HTTP.Port))
 
 	<-quit
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	stop()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer func() {
-		dbCloseCtx, dbCloseCancel := context.WithTimeout(
-			context.Background(),
-			time.Duration(cfg.PostgreSQL.ShutdownTimeout)*time.Second,
-		)
-		defer dbCloseCancel()
-		err = dbConn.Close(dbCloseCtx)
-		if err != nil {
-			log.Error("failed to close database connection:", err)
+		if replicaPool != nil {
+			replicaPool.Close()
+		}
+		if dbPool != nil {
+			err = postgres.Close(dbPool, time.Duration(cfg.PostgreSQL.ShutdownTimeout)*time.Second)
+			if err != nil {
+				log.Error("failed to close database connection:", err)
+			}
+			log.Info("Closed database connection")
 		}
-		log.Info("Closed database connection")
 		cancel()
 	}()
 
-	if err = srv.Shutdown(ctx); err != nil {
+	if err = srv.Shutdown(shutdownCtx); err != nil {
 		log.Error("server shutdown failed:", err)
 	}
 	log.Info("Server has been shutted down")
-}
 
-func loadAllCache(log logger.Logger, dbConn *pgx.Conn, cache *cache.Cache) error {
-	if err := task.CacheForTask(dbConn, cache); err != nil {
-		log.Error("Failed to load task data into cache:", err)
-		return err
+	if snapshotter != nil {
+		snapshotter.Save()
 	}
-	return nil
 }
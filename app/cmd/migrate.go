@@ -0,0 +1,85 @@
+package main
+
+import (
+	"Sber/app/pkg/config"
+	"Sber/app/pkg/logger"
+	"Sber/app/pkg/migrate"
+	postgres "Sber/app/pkg/storage"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// runMigrate implements the "migrate [flags] up|down|status" subcommand.
+func runMigrate(log logger.Logger, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	configPath := flags.String("config-path", "config.yml", "path for application configuration file")
+	dryRun := flags.Bool("dry-run", false, "only print the migrations that would be applied or rolled back")
+	steps := flags.Int("steps", 1, "number of migrations to roll back with down")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s migrate [flags] up|down|status\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	command := flags.Arg(0)
+	if command == "" {
+		command = "up"
+	}
+
+	cfg := config.GetConfig(*configPath, ".env")
+	if cfg.PostgreSQL.DSN == "" {
+		return errors.New("DATABASE_DSN is required to run migrations")
+	}
+	ctx := context.Background()
+	dbPool, err := postgres.ConnectWithRetry(ctx, *cfg, log)
+	if err != nil {
+		return err
+	}
+	defer dbPool.Close()
+
+	migrator, err := migrate.NewMigrator(dbPool, log, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		return migrator.Down(ctx, *steps)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.Applied {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown migrate command %q", command)
+	}
+}
+
+func applyMigrations(log logger.Logger, dbPool *pgxpool.Pool) error {
+	migrator, err := migrate.NewMigrator(dbPool, log, false)
+	if err != nil {
+		return err
+	}
+	return migrator.Up(context.Background())
+}
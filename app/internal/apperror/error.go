@@ -13,6 +13,8 @@ var (
 		"help")
 	ErrEmptyString        = errors.New("empty string")
 	ErrInvalidRequestBody = errors.New("invalid request body")
+	ErrUnavailable        = errors.New("storage is temporarily unavailable")
+	ErrVersionMismatch    = errors.New("task has been changed since it was read")
 )
 
 type AppError struct {
@@ -2,14 +2,224 @@ package cache
 
 import (
 	"Sber/app/internal/model"
+	"sort"
+	"sync"
+	"time"
 )
 
-type Cache struct {
-	Task map[int64]*model.Task
+// Cache keeps tasks in process memory. Implementations must be safe for
+// concurrent use and must not hand out pointers to the tasks they store:
+// Set copies its argument and Get, List and Filter return copies.
+type Cache interface {
+	Get(id int64) (*model.Task, bool)
+	Set(task *model.Task)
+	Delete(id int64)
+	// List returns every cached task ordered by ID.
+	List() []*model.Task
+	// Filter returns the cached tasks for which match returns true, ordered by ID.
+	Filter(match func(task *model.Task) bool) []*model.Task
+	// Page returns at most limit cached tasks with IDs greater than afterID,
+	// ordered by ID, or all of them when limit is 0. Only the returned tasks
+	// are copied.
+	Page(afterID int64, limit int) []*model.Task
+	// ByStatus is Page for the tasks with the given status.
+	ByStatus(status bool, afterID int64, limit int) []*model.Task
+	// ByDateRange is Page for the tasks with the given status dated from
+	// from up to but not including to.
+	ByDateRange(from, to time.Time, status bool, afterID int64, limit int) []*model.Task
+	Len() int
+	// Complete reports whether the cache holds every task, so that list
+	// queries may be answered from it without asking the storage.
+	Complete() bool
+	// SetComplete is called by whoever loads the cache once it holds every
+	// task, and with false when that stops being true.
+	SetComplete(complete bool)
+	// Generation changes whenever the cached tasks change, and is never
+	// repeated by another cache, even across restarts. LastModified is the
+	// time of the latest change. Together they validate responses built from
+	// the cache without looking at the tasks.
+	Generation() uint64
+	LastModified() time.Time
 }
 
-func NewCache() *Cache {
-	return &Cache{
-		Task: make(map[int64]*model.Task),
+var _ Cache = &memoryCache{}
+
+type memoryCache struct {
+	mu       sync.RWMutex
+	task     map[int64]model.Task
+	index    *index
+	complete bool
+	changes  changes
+}
+
+func NewCache() Cache {
+	return &memoryCache{
+		task:    make(map[int64]model.Task),
+		index:   newIndex(),
+		changes: newChanges(),
+	}
+}
+
+// New returns an unbounded cache, or an LRU cache if either bound is set.
+func New(maxEntries int, ttl time.Duration) Cache {
+	if maxEntries > 0 || ttl > 0 {
+		return NewLRU(maxEntries, ttl)
+	}
+	return NewCache()
+}
+
+func (c *memoryCache) Get(id int64) (*model.Task, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	task, ok := c.task[id]
+	if !ok {
+		return nil, false
+	}
+	return &task, true
+}
+
+func (c *memoryCache) Set(task *model.Task) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if old, ok := c.task[task.ID]; ok {
+		c.index.remove(&old)
+	}
+	c.task[task.ID] = *task
+	c.index.add(task)
+	c.changes.bump()
+}
+
+func (c *memoryCache) Delete(id int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if old, ok := c.task[id]; ok {
+		c.index.remove(&old)
+		delete(c.task, id)
+		c.changes.bump()
 	}
 }
+
+func (c *memoryCache) List() []*model.Task {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.collect(c.index.all())
+}
+
+func (c *memoryCache) Page(afterID int64, limit int) []*model.Task {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.collect(c.index.page(afterID, limit))
+}
+
+func (c *memoryCache) ByStatus(status bool, afterID int64, limit int) []*model.Task {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.collect(c.index.byStatus(status, afterID, limit))
+}
+
+func (c *memoryCache) ByDateRange(from, to time.Time, status bool, afterID int64, limit int) []*model.Task {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.collect(c.index.byDays(from, to, afterID, limit, func(id int64) bool {
+		task := c.task[id]
+		return matchDate(&task, from, to, status)
+	}))
+}
+
+func (c *memoryCache) Filter(match func(task *model.Task) bool) []*model.Task {
+	c.mu.RLock()
+	tasks := make([]*model.Task, 0)
+	for _, task := range c.task {
+		task := task
+		if match(&task) {
+			tasks = append(tasks, &task)
+		}
+	}
+	c.mu.RUnlock()
+
+	sortByID(tasks)
+	return tasks
+}
+
+func (c *memoryCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.task)
+}
+
+func (c *memoryCache) Complete() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.complete
+}
+
+func (c *memoryCache) SetComplete(complete bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.complete != complete {
+		c.complete = complete
+		c.changes.bump()
+	}
+}
+
+func (c *memoryCache) Generation() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.changes.generation
+}
+
+func (c *memoryCache) LastModified() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.changes.modified
+}
+
+func (c *memoryCache) collect(ids []int64) []*model.Task {
+	tasks := make([]*model.Task, 0, len(ids))
+	for _, id := range ids {
+		task := c.task[id]
+		tasks = append(tasks, &task)
+	}
+	return tasks
+}
+
+// changes tracks the generation and modification time of a cache. The
+// generation starts from the creation time in nanoseconds, so that a
+// restarted process does not hand out the generations of the previous one.
+type changes struct {
+	generation uint64
+	modified   time.Time
+}
+
+func newChanges() changes {
+	now := time.Now()
+	return changes{generation: uint64(now.UnixNano()), modified: now}
+}
+
+func (c *changes) bump() {
+	c.generation++
+	c.modified = time.Now()
+}
+
+// inRange keeps the tasks dated within [from, to) and orders them by ID.
+func matchDate(task *model.Task, from, to time.Time, status bool) bool {
+	return task.Status == status && !task.Date.Before(from) && task.Date.Before(to)
+}
+
+func sortByID(tasks []*model.Task) {
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].ID < tasks[j].ID
+	})
+}
@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"Sber/app/internal/model"
+	"math"
+	"sort"
+	"time"
+)
+
+// index keeps the IDs of cached tasks grouped by status and by UTC calendar
+// day. Every group is kept sorted by ID so lookups need no sorting.
+type index struct {
+	status map[bool][]int64
+	day    map[int][]int64
+}
+
+func newIndex() *index {
+	return &index{
+		status: make(map[bool][]int64),
+		day:    make(map[int][]int64),
+	}
+}
+
+func (x *index) add(task *model.Task) {
+	x.status[task.Status] = insertID(x.status[task.Status], task.ID)
+	key := dayKey(task.Date)
+	x.day[key] = insertID(x.day[key], task.ID)
+}
+
+func (x *index) remove(task *model.Task) {
+	x.status[task.Status] = removeID(x.status[task.Status], task.ID)
+	key := dayKey(task.Date)
+	if ids := removeID(x.day[key], task.ID); len(ids) > 0 {
+		x.day[key] = ids
+	} else {
+		delete(x.day, key)
+	}
+}
+
+// all returns the IDs of every indexed task in ascending order.
+func (x *index) all() []int64 {
+	return x.page(math.MinInt64, 0)
+}
+
+// page returns in ascending order at most limit IDs greater than afterID,
+// every one of them when limit is 0.
+func (x *index) page(afterID int64, limit int) []int64 {
+	done, open := idsAfter(x.status[true], afterID), idsAfter(x.status[false], afterID)
+	n := len(done) + len(open)
+	if limit > 0 && limit < n {
+		n = limit
+	}
+	ids := make([]int64, 0, n)
+	for len(ids) < n {
+		if len(open) == 0 || len(done) > 0 && done[0] < open[0] {
+			ids, done = append(ids, done[0]), done[1:]
+		} else {
+			ids, open = append(ids, open[0]), open[1:]
+		}
+	}
+	return ids
+}
+
+// byStatus is page for the tasks with the given status.
+func (x *index) byStatus(status bool, afterID int64, limit int) []int64 {
+	ids := idsAfter(x.status[status], afterID)
+	if limit > 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids
+}
+
+// byDays is page for the tasks dated on the UTC days that overlap [from, to)
+// and for which keep returns true. The days are merged by ID as they are
+// read, so only the IDs up to the last one returned are looked at.
+func (x *index) byDays(from, to time.Time, afterID int64, limit int, keep func(id int64) bool) []int64 {
+	days := x.days(from, to)
+	for i := range days {
+		days[i] = idsAfter(days[i], afterID)
+	}
+	ids := make([]int64, 0)
+	for limit <= 0 || len(ids) < limit {
+		next := -1
+		for i, day := range days {
+			if len(day) > 0 && (next < 0 || day[0] < days[next][0]) {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		id := days[next][0]
+		days[next] = days[next][1:]
+		if keep(id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// days returns the ID lists of the UTC days that overlap [from, to) and have
+// tasks. Tasks on the first and the last day may fall outside the range.
+func (x *index) days(from, to time.Time) [][]int64 {
+	days := make([][]int64, 0)
+	if !from.Before(to) {
+		return days
+	}
+	first, last := dayKey(from), dayKey(to.Add(-time.Nanosecond))
+
+	// A long range is cheaper to check against the days that have tasks.
+	if n := int(to.Sub(from).Hours()/24) + 1; n > len(x.day) {
+		for key, ids := range x.day {
+			if key >= first && key <= last {
+				days = append(days, ids)
+			}
+		}
+		return days
+	}
+	for day := from.UTC(); dayKey(day) <= last; day = day.AddDate(0, 0, 1) {
+		if ids := x.day[dayKey(day)]; len(ids) > 0 {
+			days = append(days, ids)
+		}
+	}
+	return days
+}
+
+// dayKey identifies the UTC calendar day of t as yyyymmdd.
+func dayKey(t time.Time) int {
+	year, month, day := t.UTC().Date()
+	return year*10000 + int(month)*100 + day
+}
+
+// idsAfter returns the tail of the sorted ids that are greater than afterID.
+func idsAfter(ids []int64, afterID int64) []int64 {
+	return ids[sort.Search(len(ids), func(i int) bool { return ids[i] > afterID }):]
+}
+
+func insertID(ids []int64, id int64) []int64 {
+	i := sort.Search(len(ids), func(i int) bool { return ids[i] >= id })
+	if i < len(ids) && ids[i] == id {
+		return ids
+	}
+	ids = append(ids, 0)
+	copy(ids[i+1:], ids[i:])
+	ids[i] = id
+	return ids
+}
+
+func removeID(ids []int64, id int64) []int64 {
+	i := sort.Search(len(ids), func(i int) bool { return ids[i] >= id })
+	if i == len(ids) || ids[i] != id {
+		return ids
+	}
+	return append(ids[:i], ids[i+1:]...)
+}
@@ -0,0 +1,211 @@
+package cache
+
+import (
+	"Sber/app/internal/model"
+	"container/list"
+	"sync"
+	"time"
+)
+
+var _ Cache = &lruCache{}
+
+type lruEntry struct {
+	task      model.Task
+	expiresAt time.Time
+}
+
+// lruCache holds at most maxEntries tasks, evicting the least recently used
+// one when full, and forgets entries older than ttl. A zero maxEntries or ttl
+// disables the corresponding bound.
+type lruCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	items      map[int64]*list.Element
+	order      *list.List
+	index      *index
+	changes    changes
+}
+
+func NewLRU(maxEntries int, ttl time.Duration) Cache {
+	return &lruCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		items:      make(map[int64]*list.Element),
+		order:      list.New(),
+		index:      newIndex(),
+		changes:    newChanges(),
+	}
+}
+
+func (c *lruCache) Get(id int64) (*model.Task, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[id]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if c.expired(entry, time.Now()) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+
+	task := entry.task
+	return &task, true
+}
+
+func (c *lruCache) Set(task *model.Task) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expiresAt time.Time
+	if c.ttl > 0 {
+		expiresAt = time.Now().Add(c.ttl)
+	}
+
+	if elem, ok := c.items[task.ID]; ok {
+		c.index.remove(&elem.Value.(*lruEntry).task)
+		c.index.add(task)
+		elem.Value = &lruEntry{task: *task, expiresAt: expiresAt}
+		c.order.MoveToFront(elem)
+		c.changes.bump()
+		return
+	}
+
+	c.items[task.ID] = c.order.PushFront(&lruEntry{task: *task, expiresAt: expiresAt})
+	c.index.add(task)
+	c.changes.bump()
+	if c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *lruCache) Delete(id int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[id]; ok {
+		c.remove(elem)
+	}
+}
+
+func (c *lruCache) List() []*model.Task {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.removeExpired()
+	return c.collect(c.index.all())
+}
+
+func (c *lruCache) Page(afterID int64, limit int) []*model.Task {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.removeExpired()
+	return c.collect(c.index.page(afterID, limit))
+}
+
+func (c *lruCache) ByStatus(status bool, afterID int64, limit int) []*model.Task {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.removeExpired()
+	return c.collect(c.index.byStatus(status, afterID, limit))
+}
+
+func (c *lruCache) ByDateRange(from, to time.Time, status bool, afterID int64, limit int) []*model.Task {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.removeExpired()
+	return c.collect(c.index.byDays(from, to, afterID, limit, func(id int64) bool {
+		return matchDate(&c.items[id].Value.(*lruEntry).task, from, to, status)
+	}))
+}
+
+func (c *lruCache) Filter(match func(task *model.Task) bool) []*model.Task {
+	c.mu.Lock()
+	c.removeExpired()
+	tasks := make([]*model.Task, 0)
+	for _, elem := range c.items {
+		task := elem.Value.(*lruEntry).task
+		if match(&task) {
+			tasks = append(tasks, &task)
+		}
+	}
+	c.mu.Unlock()
+
+	sortByID(tasks)
+	return tasks
+}
+
+func (c *lruCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.removeExpired()
+	return c.order.Len()
+}
+
+// Complete is always false: a bounded cache holds only a subset of the tasks.
+func (c *lruCache) Complete() bool {
+	return false
+}
+
+func (c *lruCache) SetComplete(bool) {}
+
+func (c *lruCache) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.removeExpired()
+	return c.changes.generation
+}
+
+func (c *lruCache) LastModified() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.removeExpired()
+	return c.changes.modified
+}
+
+func (c *lruCache) expired(entry *lruEntry, now time.Time) bool {
+	return c.ttl > 0 && now.After(entry.expiresAt)
+}
+
+func (c *lruCache) removeExpired() {
+	if c.ttl == 0 {
+		return
+	}
+	now := time.Now()
+	for id, elem := range c.items {
+		if entry := elem.Value.(*lruEntry); c.expired(entry, now) {
+			c.order.Remove(elem)
+			c.index.remove(&entry.task)
+			delete(c.items, id)
+			c.changes.bump()
+		}
+	}
+}
+
+func (c *lruCache) remove(elem *list.Element) {
+	entry := elem.Value.(*lruEntry)
+	c.order.Remove(elem)
+	c.index.remove(&entry.task)
+	delete(c.items, entry.task.ID)
+	c.changes.bump()
+}
+
+// collect does not count as a use of the returned entries.
+func (c *lruCache) collect(ids []int64) []*model.Task {
+	tasks := make([]*model.Task, 0, len(ids))
+	for _, id := range ids {
+		task := c.items[id].Value.(*lruEntry).task
+		tasks = append(tasks, &task)
+	}
+	return tasks
+}
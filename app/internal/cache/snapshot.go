@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"Sber/app/internal/model"
+	"Sber/app/pkg/logger"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// snapshotVersion is bumped whenever the snapshot layout or model.Task changes
+// incompatibly; snapshots of any other version are ignored.
+const snapshotVersion = 3
+
+type snapshot struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// Watermark is the latest UpdatedAt among the saved tasks.
+	Watermark time.Time `json:"watermark"`
+	// Checksum is the hex SHA-256 of Tasks as stored in the file.
+	Checksum string          `json:"checksum"`
+	Tasks    json.RawMessage `json:"tasks"`
+}
+
+// SaveSnapshot writes every cached task to path. The file is replaced
+// atomically, so a crash while saving leaves the previous snapshot intact.
+func SaveSnapshot(path string, c Cache) error {
+	tasks := c.List()
+	var watermark time.Time
+	for _, task := range tasks {
+		if task.UpdatedAt.After(watermark) {
+			watermark = task.UpdatedAt
+		}
+	}
+
+	raw, err := json.Marshal(tasks)
+	if err != nil {
+		return fmt.Errorf("cannot encode tasks: %v", err)
+	}
+	sum := sha256.Sum256(raw)
+	data, err := json.Marshal(snapshot{
+		Version:   snapshotVersion,
+		CreatedAt: time.Now().UTC(),
+		Watermark: watermark,
+		Checksum:  hex.EncodeToString(sum[:]),
+		Tasks:     raw,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot encode snapshot: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("cannot create snapshot file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("cannot write snapshot: %v", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("cannot write snapshot: %v", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("cannot write snapshot: %v", err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("cannot replace snapshot: %v", err)
+	}
+	return nil
+}
+
+// LoadSnapshot fills c with the tasks saved at path and returns the snapshot
+// watermark. Nothing is added to c unless the version and checksum match.
+// A missing file is reported with an error wrapping fs.ErrNotExist.
+func LoadSnapshot(path string, c Cache) (time.Time, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var snap snapshot
+	if err = json.Unmarshal(data, &snap); err != nil {
+		return time.Time{}, fmt.Errorf("cannot decode snapshot: %v", err)
+	}
+	if snap.Version != snapshotVersion {
+		return time.Time{}, fmt.Errorf("unsupported snapshot version %d, want %d", snap.Version, snapshotVersion)
+	}
+	sum := sha256.Sum256(snap.Tasks)
+	if hex.EncodeToString(sum[:]) != snap.Checksum {
+		return time.Time{}, fmt.Errorf("snapshot checksum mismatch")
+	}
+
+	var tasks []model.Task
+	if err = json.Unmarshal(snap.Tasks, &tasks); err != nil {
+		return time.Time{}, fmt.Errorf("cannot decode snapshot tasks: %v", err)
+	}
+	for i := range tasks {
+		c.Set(&tasks[i])
+	}
+	return snap.Watermark, nil
+}
+
+/// Структура Snapshotter периодически сохраняющая кэш на диск \\\
+
+type Snapshotter struct {
+	log      logger.Logger
+	cache    Cache
+	path     string
+	interval time.Duration
+}
+
+func NewSnapshotter(cache Cache, path string, interval time.Duration) *Snapshotter {
+	return &Snapshotter{
+		log:      logger.GetLogger(),
+		cache:    cache,
+		path:     path,
+		interval: interval,
+	}
+}
+
+// Run saves a snapshot every interval until ctx is cancelled. With an
+// interval that is not positive it saves nothing periodically.
+func (s *Snapshotter) Run(ctx context.Context) {
+	if s.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Save()
+		}
+	}
+}
+
+// Save writes a snapshot now. Only a complete cache is saved: a partial one
+// would be mistaken for the whole table on the next start.
+func (s *Snapshotter) Save() {
+	if !s.cache.Complete() {
+		return
+	}
+	if err := SaveSnapshot(s.path, s.cache); err != nil {
+		s.log.Errorf("SNAPSHOT: %v", err)
+		return
+	}
+	s.log.Infof("SNAPSHOT: saved %d tasks to %s", s.cache.Len(), s.path)
+}
@@ -8,4 +8,7 @@ type Task struct {
 	Description string    `json:"description"`
 	Date        time.Time `json:"date"`
 	Status      bool      `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Version     int64     `json:"version"`
 }
@@ -20,3 +20,13 @@ func NotFound(w http.ResponseWriter) {
 func InternalError(w http.ResponseWriter, message, developerMessage string) {
 	Error(w, http.StatusInternalServerError, message, developerMessage)
 }
+
+// ServiceUnavailable is answered while the storage cannot be reached; the
+// caller is expected to set Retry-After.
+func ServiceUnavailable(w http.ResponseWriter, message string) {
+	Error(w, http.StatusServiceUnavailable, message, "")
+}
+
+func PreconditionFailed(w http.ResponseWriter, message string) {
+	Error(w, http.StatusPreconditionFailed, message, "")
+}
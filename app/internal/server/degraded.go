@@ -0,0 +1,58 @@
+package server
+
+import (
+	"Sber/app/pkg/breaker"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// staleWarning marks responses served from the cache while the database is
+// unavailable (RFC 7234, section 5.5.1).
+const staleWarning = `110 - "Response is Stale"`
+
+// degraded adds a Warning header to successful responses and a Retry-After
+// header to 503 responses while the database breaker is not closed.
+func degraded(next http.Handler, b *breaker.Breaker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&degradedWriter{ResponseWriter: w, breaker: b}, r)
+	})
+}
+
+type degradedWriter struct {
+	http.ResponseWriter
+	breaker     *breaker.Breaker
+	wroteHeader bool
+}
+
+func (w *degradedWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	if w.breaker.State() != breaker.Closed {
+		switch {
+		case code == http.StatusServiceUnavailable:
+			w.Header().Set("Retry-After", strconv.Itoa(retrySeconds(w.breaker.RetryAfter())))
+		case code < http.StatusMultipleChoices:
+			w.Header().Set("Warning", staleWarning)
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *degradedWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func retrySeconds(d time.Duration) int {
+	seconds := int((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
@@ -2,13 +2,16 @@ package server
 
 import (
 	"Sber/app/internal/cache"
+	"Sber/app/internal/response"
 	"Sber/app/internal/task"
+	"Sber/app/pkg/breaker"
 	"Sber/app/pkg/config"
 	"Sber/app/pkg/logger"
+	postgres "Sber/app/pkg/storage"
 	_ "Sber/docs"
 	"context"
 	"fmt"
-	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/browser"
 	httpSwagger "github.com/swaggo/http-swagger"
@@ -21,10 +24,11 @@ type Server struct {
 	log     *logger.Logger
 	cfg     *config.Config
 	handler *httprouter.Router
-	cache   *cache.Cache
+	cache   cache.Cache
+	cancel  context.CancelFunc
 }
 
-func NewServer(cfg *config.Config, handler *httprouter.Router, log *logger.Logger, cache *cache.Cache) *Server {
+func NewServer(cfg *config.Config, handler *httprouter.Router, log *logger.Logger, cache cache.Cache) *Server {
 	return &Server{
 		srv: &http.Server{
 			Handler:      handler,
@@ -39,16 +43,76 @@ func NewServer(cfg *config.Config, handler *httprouter.Router, log *logger.Logge
 	}
 }
 
-func (s *Server) Run(dbConn *pgx.Conn) error {
+const (
+	dbStatsURL = "/db_stats"
+	// readyURL answers 503 with the warm-up progress until the cache is loaded.
+	readyURL = "/ready"
+)
+
+func (s *Server) Run(dbPool, replicaPool *pgxpool.Pool) error {
 
 	reqTimeout := s.cfg.PostgreSQL.RequestTimeout
 
-	taskStorage := task.NewStorage(dbConn, reqTimeout, s.cache)
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	warmup := task.NewWarmup(dbPool, s.cache, s.cfg.Cache.WarmupPageSize, s.cfg.Cache.SnapshotPath,
+		s.cfg.Cache.Policy == config.CacheWriteThrough)
+
+	var taskStorage task.Storage
+	if s.cfg.Storage.Type == config.StorageMemory {
+		taskStorage = task.NewMemoryStorage(s.cache, s.cfg.Cache.Policy)
+		warmup.Skip()
+		s.log.Info("Using in-memory task storage")
+	} else {
+		dbBreaker := breaker.New(s.cfg.PostgreSQL.BreakerThreshold,
+			time.Duration(s.cfg.PostgreSQL.BreakerCooldown)*time.Second)
+		taskStorage = task.NewBreakerStorage(
+			task.NewStorage(dbPool, replicaPool, reqTimeout, s.cfg.PostgreSQL.ReadYourWrites, s.cache, s.cfg.Cache.Policy), dbBreaker, s.cache)
+		s.srv.Handler = degraded(s.handler, dbBreaker)
+		switch {
+		case s.cfg.Cache.MaxEntries > 0 || s.cfg.Cache.TTL > 0:
+			// A bounded cache is filled on first read.
+			warmup.Skip()
+		case dbPool == nil:
+			s.log.Error("Cache warm-up needs a database connection, the service stays not ready")
+		default:
+			go warmup.Run(ctx)
+			s.log.Info("Started cache warm-up")
+		}
+		if s.cfg.Cache.ListenNotify {
+			go task.NewListener(dbPool, taskStorage, s.cache).Run(ctx)
+			s.log.Info("Started task change listener")
+		}
+	}
+	if s.cfg.Storage.TrashRetention > 0 {
+		go task.NewPurger(taskStorage,
+			time.Duration(s.cfg.Storage.TrashRetention)*24*time.Hour,
+			time.Duration(s.cfg.Storage.PurgeInterval)*time.Second).Run(ctx)
+		s.log.Info("Started trash purger")
+	}
 	taskService := task.NewService(taskStorage, *s.log)
 	taskHandler := task.NewHandler(*s.log, taskService, s.cache)
 	taskHandler.Register(s.handler)
 	s.log.Info("Initialized task routes")
 
+	s.handler.HandlerFunc(http.MethodGet, readyURL, func(w http.ResponseWriter, r *http.Request) {
+		status := warmup.Status()
+		if status.State != task.WarmupReady {
+			response.JSON(w, http.StatusServiceUnavailable, status)
+			return
+		}
+		response.JSON(w, http.StatusOK, status)
+	})
+	s.log.Info("Initialized readiness route")
+
+	if dbPool != nil {
+		s.handler.HandlerFunc(http.MethodGet, dbStatsURL, func(w http.ResponseWriter, r *http.Request) {
+			response.JSON(w, http.StatusOK, postgres.Stats(dbPool))
+		})
+		s.log.Info("Initialized database pool statistics route")
+	}
+
 	s.handler.Handler(http.MethodGet, "/docs/*any", httpSwagger.WrapHandler)
 	s.log.Info("Initialized task documentation")
 
@@ -68,5 +132,8 @@ func (s *Server) Run(dbConn *pgx.Conn) error {
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
+	if s.cancel != nil {
+		s.cancel()
+	}
 	return s.srv.Shutdown(ctx)
 }
@@ -0,0 +1,278 @@
+package task
+
+import (
+	"Sber/app/internal/apperror"
+	"Sber/app/internal/cache"
+	"Sber/app/internal/model"
+	"Sber/app/pkg/breaker"
+	"Sber/app/pkg/logger"
+	"context"
+	"errors"
+	"github.com/jackc/pgconn"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+var _ Storage = &BreakerStorage{}
+
+/// Структура BreakerStorage отключающая хранилище при повторяющихся сбоях БД \\\
+
+// BreakerStorage stops calling the wrapped storage once the database keeps
+// failing. While the breaker is open writes fail with apperror.ErrUnavailable
+// and reads are answered from the cache when it can answer them.
+type BreakerStorage struct {
+	log     logger.Logger
+	storage Storage
+	breaker *breaker.Breaker
+	cache   cache.Cache
+}
+
+func NewBreakerStorage(storage Storage, breaker *breaker.Breaker, cache cache.Cache) Storage {
+	return &BreakerStorage{
+		log:     logger.GetLogger(),
+		storage: storage,
+		breaker: breaker,
+		cache:   cache,
+	}
+}
+
+func (b *BreakerStorage) Begin(ctx context.Context) (context.Context, Tx, error) {
+	if err := b.breaker.Allow(); err != nil {
+		return ctx, nil, apperror.ErrUnavailable
+	}
+	txCtx, tx, err := b.storage.Begin(ctx)
+	return txCtx, tx, b.done(ctx, err)
+}
+
+func (b *BreakerStorage) Create(ctx context.Context, task *Task) (*Task, error) {
+	if err := b.breaker.Allow(); err != nil {
+		return nil, apperror.ErrUnavailable
+	}
+	created, err := b.storage.Create(ctx, task)
+	return created, b.done(ctx, err)
+}
+
+func (b *BreakerStorage) FindById(ctx context.Context, id int64) (*Task, error) {
+	if err := b.breaker.Allow(); err == nil {
+		task, err := b.storage.FindById(ctx, id)
+		// A transaction must not go on with a cached copy of the row.
+		if err = b.done(ctx, err); !errors.Is(err, apperror.ErrUnavailable) || txFromContext(ctx) != nil {
+			return task, err
+		}
+	}
+	if cached, ok := b.cache.Get(id); ok {
+		return fromModel(cached), nil
+	}
+	return nil, apperror.ErrUnavailable
+}
+
+func (b *BreakerStorage) FindAll(ctx context.Context, page Page) ([]Task, error) {
+	if err := b.breaker.Allow(); err == nil {
+		tasks, err := b.storage.FindAll(ctx, page)
+		if err = b.done(ctx, err); !errors.Is(err, apperror.ErrUnavailable) {
+			return tasks, err
+		}
+	}
+	return b.fromCache(func() []*model.Task {
+		return b.cache.Page(page.After, page.Limit)
+	})
+}
+
+func (b *BreakerStorage) FindAllStatus(ctx context.Context, status bool, page Page) ([]Task, error) {
+	if err := b.breaker.Allow(); err == nil {
+		tasks, err := b.storage.FindAllStatus(ctx, status, page)
+		if err = b.done(ctx, err); !errors.Is(err, apperror.ErrUnavailable) {
+			return tasks, err
+		}
+	}
+	return b.fromCache(func() []*model.Task {
+		return b.cache.ByStatus(status, page.After, page.Limit)
+	})
+}
+
+func (b *BreakerStorage) FindDateAllAvailable(ctx context.Context, dates DateRange, status bool, page Page) ([]Task, error) {
+	if err := b.breaker.Allow(); err == nil {
+		tasks, err := b.storage.FindDateAllAvailable(ctx, dates, status, page)
+		if err = b.done(ctx, err); !errors.Is(err, apperror.ErrUnavailable) {
+			return tasks, err
+		}
+	}
+	return b.fromCache(func() []*model.Task {
+		return b.cache.ByDateRange(dates.From, dates.To, status, page.After, page.Limit)
+	})
+}
+
+func (b *BreakerStorage) FindByQuery(ctx context.Context, q *Query) ([]Task, error) {
+	if err := b.breaker.Allow(); err == nil {
+		tasks, err := b.storage.FindByQuery(ctx, q)
+		if err = b.done(ctx, err); !errors.Is(err, apperror.ErrUnavailable) {
+			return tasks, err
+		}
+	}
+	return b.fromCache(func() []*model.Task {
+		return q.Apply(b.cache.List())
+	})
+}
+
+// Search has no cache fallback: the cache cannot rank or highlight.
+func (b *BreakerStorage) Search(ctx context.Context, text string, limit int) ([]SearchResult, error) {
+	if err := b.breaker.Allow(); err != nil {
+		return nil, apperror.ErrUnavailable
+	}
+	results, err := b.storage.Search(ctx, text, limit)
+	return results, b.done(ctx, err)
+}
+
+func (b *BreakerStorage) Update(ctx context.Context, task *Task) (*Task, error) {
+	if err := b.breaker.Allow(); err != nil {
+		return nil, apperror.ErrUnavailable
+	}
+	updated, err := b.storage.Update(ctx, task)
+	return updated, b.done(ctx, err)
+}
+
+func (b *BreakerStorage) PartiallyUpdate(ctx context.Context, task *PartiallyUpdateTask) (*Task, error) {
+	if err := b.breaker.Allow(); err != nil {
+		return nil, apperror.ErrUnavailable
+	}
+	updated, err := b.storage.PartiallyUpdate(ctx, task)
+	return updated, b.done(ctx, err)
+}
+
+func (b *BreakerStorage) Delete(ctx context.Context, id int64) error {
+	if err := b.breaker.Allow(); err != nil {
+		return apperror.ErrUnavailable
+	}
+	return b.done(ctx, b.storage.Delete(ctx, id))
+}
+
+// FindByIds has no cache fallback: it is used to lock rows before changing
+// them.
+func (b *BreakerStorage) FindByIds(ctx context.Context, ids []int64) ([]Task, error) {
+	if err := b.breaker.Allow(); err != nil {
+		return nil, apperror.ErrUnavailable
+	}
+	tasks, err := b.storage.FindByIds(ctx, ids)
+	return tasks, b.done(ctx, err)
+}
+
+func (b *BreakerStorage) CreateMany(ctx context.Context, tasks []*Task) ([]*Task, error) {
+	if err := b.breaker.Allow(); err != nil {
+		return nil, apperror.ErrUnavailable
+	}
+	created, err := b.storage.CreateMany(ctx, tasks)
+	return created, b.done(ctx, err)
+}
+
+func (b *BreakerStorage) PartiallyUpdateMany(ctx context.Context, tasks []*PartiallyUpdateTask) ([]*Task, error) {
+	if err := b.breaker.Allow(); err != nil {
+		return nil, apperror.ErrUnavailable
+	}
+	updated, err := b.storage.PartiallyUpdateMany(ctx, tasks)
+	return updated, b.done(ctx, err)
+}
+
+func (b *BreakerStorage) DeleteMany(ctx context.Context, ids []int64) ([]int64, error) {
+	if err := b.breaker.Allow(); err != nil {
+		return nil, apperror.ErrUnavailable
+	}
+	deleted, err := b.storage.DeleteMany(ctx, ids)
+	return deleted, b.done(ctx, err)
+}
+
+// FindDeleted has no cache fallback: the cache holds only live tasks.
+func (b *BreakerStorage) FindDeleted(ctx context.Context) ([]Task, error) {
+	if err := b.breaker.Allow(); err != nil {
+		return nil, apperror.ErrUnavailable
+	}
+	tasks, err := b.storage.FindDeleted(ctx)
+	return tasks, b.done(ctx, err)
+}
+
+func (b *BreakerStorage) Restore(ctx context.Context, id int64) (*Task, error) {
+	if err := b.breaker.Allow(); err != nil {
+		return nil, apperror.ErrUnavailable
+	}
+	restored, err := b.storage.Restore(ctx, id)
+	return restored, b.done(ctx, err)
+}
+
+func (b *BreakerStorage) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	if err := b.breaker.Allow(); err != nil {
+		return 0, apperror.ErrUnavailable
+	}
+	purged, err := b.storage.Purge(ctx, deletedBefore)
+	return purged, b.done(ctx, err)
+}
+
+func (b *BreakerStorage) AddHistory(ctx context.Context, entries ...*HistoryEntry) error {
+	if err := b.breaker.Allow(); err != nil {
+		return apperror.ErrUnavailable
+	}
+	return b.done(ctx, b.storage.AddHistory(ctx, entries...))
+}
+
+func (b *BreakerStorage) FindHistory(ctx context.Context, taskID int64) ([]HistoryEntry, error) {
+	if err := b.breaker.Allow(); err != nil {
+		return nil, apperror.ErrUnavailable
+	}
+	entries, err := b.storage.FindHistory(ctx, taskID)
+	return entries, b.done(ctx, err)
+}
+
+// done records the outcome of a call let through by the breaker. Errors that
+// tripped the breaker are replaced with apperror.ErrUnavailable, so that
+// reads can fall back to the cache.
+func (b *BreakerStorage) done(ctx context.Context, err error) error {
+	switch {
+	case err == nil || errors.Is(err, apperror.ErrEmptyString):
+		b.breaker.Success()
+		return err
+	case errors.Is(ctx.Err(), context.Canceled):
+		// The client went away; that says nothing about the database.
+		b.breaker.Release()
+		return err
+	case !databaseDown(err):
+		// The database answered, only it rejected the query.
+		b.breaker.Success()
+		return err
+	}
+
+	b.breaker.Failure()
+	if b.breaker.State() == breaker.Closed {
+		return err
+	}
+	b.log.Errorf("BREAKER: storage is unavailable: %v", err)
+	return apperror.ErrUnavailable
+}
+
+// databaseDown reports whether err means the database cannot be reached or
+// cannot serve queries, rather than that it rejected one, say for bad input.
+func databaseDown(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Connection exceptions, insufficient resources and operator
+		// intervention such as a shutdown.
+		return strings.HasPrefix(pgErr.Code, "08") || strings.HasPrefix(pgErr.Code, "53") ||
+			strings.HasPrefix(pgErr.Code, "57P")
+	}
+	var netErr net.Error
+	return pgconn.Timeout(err) || errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// fromCache answers a list query from the cache, which is possible only when
+// the cache holds every task.
+func (b *BreakerStorage) fromCache(list func() []*model.Task) ([]Task, error) {
+	if !b.cache.Complete() {
+		return nil, apperror.ErrUnavailable
+	}
+	cached := list()
+	tasks := make([]Task, 0, len(cached))
+	for _, task := range cached {
+		tasks = append(tasks, *fromModel(task))
+	}
+	return tasks, nil
+}
@@ -0,0 +1,47 @@
+package task
+
+import (
+	"Sber/app/internal/cache"
+	"Sber/app/pkg/config"
+)
+
+// cacheWriter keeps the cache in line with the storage after every
+// successful write, according to the configured cache policy:
+//
+//   - write-through stores the fresh row, so a complete cache stays complete
+//     and can keep answering list queries;
+//   - write-invalidate drops the row, which is then read through on the next
+//     GET by ID. The cache is never complete under this policy.
+type cacheWriter struct {
+	cache      cache.Cache
+	invalidate bool
+}
+
+func newCacheWriter(cache cache.Cache, policy string) cacheWriter {
+	return cacheWriter{
+		cache:      cache,
+		invalidate: policy == config.CacheWriteInvalidate,
+	}
+}
+
+func (w cacheWriter) created(task *Task) {
+	if w.invalidate {
+		return
+	}
+	w.cache.Set(toModel(task))
+}
+
+func (w cacheWriter) updated(task *Task) {
+	if w.invalidate {
+		w.cache.Delete(task.ID)
+		return
+	}
+	// A bounded cache only refreshes tasks it already holds.
+	if _, cached := w.cache.Get(task.ID); cached || w.cache.Complete() {
+		w.cache.Set(toModel(task))
+	}
+}
+
+func (w cacheWriter) deleted(id int64) {
+	w.cache.Delete(id)
+}
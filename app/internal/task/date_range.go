@@ -0,0 +1,115 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// DateRange is the half-open interval [From, To) of task dates.
+type DateRange struct {
+	From time.Time
+	To   time.Time
+}
+
+func (r DateRange) Contains(t time.Time) bool {
+	return !t.Before(r.From) && t.Before(r.To)
+}
+
+// @Example DateRangeQuery
+// {
+// "date": "2023-09-21",
+// "period": "week",
+// "time_zone": "Europe/Moscow",
+// "status": false
+// }
+type DateRangeQuery struct {
+	// Date picks the calendar day, week (Monday to Sunday) or month given by
+	// Period, day by default. From and To give an arbitrary range instead.
+	Date   string `json:"date,omitempty" example:"2023-09-21"`
+	Period string `json:"period,omitempty" example:"week"`
+	From   string `json:"from,omitempty" example:"2023-09-01"`
+	To     string `json:"to,omitempty" example:"2023-10-01T12:00"`
+	// TimeZone is the IANA zone in which the calendar is read and times
+	// without an offset are taken, UTC by default.
+	TimeZone string `json:"time_zone,omitempty" example:"Europe/Moscow"`
+	Status   bool   `json:"status" example:"false"`
+}
+
+// dateLayouts are tried in order. Only the first one carries an offset; the
+// others are read in the query time zone.
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// Range resolves the query into the interval of dates it selects.
+func (q *DateRangeQuery) Range() (DateRange, error) {
+	loc := time.UTC
+	if q.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(q.TimeZone); err != nil {
+			return DateRange{}, fmt.Errorf("unknown time zone %q", q.TimeZone)
+		}
+	}
+
+	if q.From != "" || q.To != "" {
+		if q.Date != "" || q.Period != "" {
+			return DateRange{}, errors.New("from and to cannot be combined with date and period")
+		}
+		if q.From == "" || q.To == "" {
+			return DateRange{}, errors.New("from and to must be given together")
+		}
+		from, err := parseDate(q.From, loc)
+		if err != nil {
+			return DateRange{}, err
+		}
+		to, err := parseDate(q.To, loc)
+		if err != nil {
+			return DateRange{}, err
+		}
+		if !from.Before(to) {
+			return DateRange{}, errors.New("from must be before to")
+		}
+		return DateRange{From: from, To: to}, nil
+	}
+
+	if q.Date == "" {
+		return DateRange{}, errors.New("date or from and to are required")
+	}
+	date, err := parseDate(q.Date, loc)
+	if err != nil {
+		return DateRange{}, err
+	}
+	year, month, day := date.In(loc).Date()
+	start := time.Date(year, month, day, 0, 0, 0, 0, loc)
+
+	switch q.Period {
+	case "", PeriodDay:
+		return DateRange{From: start, To: start.AddDate(0, 0, 1)}, nil
+	case PeriodWeek:
+		start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+		return DateRange{From: start, To: start.AddDate(0, 0, 7)}, nil
+	case PeriodMonth:
+		start = time.Date(year, month, 1, 0, 0, 0, 0, loc)
+		return DateRange{From: start, To: start.AddDate(0, 1, 0)}, nil
+	}
+	return DateRange{}, fmt.Errorf("period must be %s, %s or %s", PeriodDay, PeriodWeek, PeriodMonth)
+}
+
+func parseDate(value string, loc *time.Location) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot read date %q, use RFC 3339 or yyyy-mm-dd", value)
+}
@@ -0,0 +1,77 @@
+package task
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// taskETag is the strong entity tag of a task: its version.
+func taskETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// setETag sends the task version as a strong entity tag.
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", taskETag(version))
+}
+
+// listETag is the weak entity tag of a list answered from the cache. It is
+// weak because the same tasks may be encoded differently.
+func listETag(generation uint64) string {
+	return fmt.Sprintf(`W/"%x"`, generation)
+}
+
+// ifMatchVersion returns the task version required by the If-Match header,
+// 0 when any version will do, and -1 when the header matches no version.
+func ifMatchVersion(r *http.Request) int64 {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0
+	}
+	tag, err := strconv.Unquote(header)
+	if err != nil {
+		return -1
+	}
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version < 1 {
+		return -1
+	}
+	return version
+}
+
+// setValidators sends the ETag and, when known, Last-Modified of a response.
+func setValidators(w http.ResponseWriter, etag string, modified time.Time) {
+	w.Header().Set("ETag", etag)
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when the former
+// is absent, against the current validators of a GET response.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		for _, tag := range strings.Split(header, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || weakTag(tag) == weakTag(etag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || modified.IsZero() {
+		return false
+	}
+	// Last-Modified has a precision of one second.
+	return !modified.Truncate(time.Second).After(since)
+}
+
+// weakTag strips the weakness indicator: If-None-Match uses weak comparison.
+func weakTag(tag string) string {
+	return strings.TrimPrefix(tag, "W/")
+}
@@ -11,7 +11,8 @@ import (
 	"fmt"
 	"github.com/julienschmidt/httprouter"
 	"net/http"
-	"sort"
+	"strconv"
+	"strings"
 )
 
 const (
@@ -20,15 +21,24 @@ const (
 	taskAllStatusURL = "/task_all_status"
 	taskAvailableURL = "/task_all_available"
 	taskIdURL        = "/task/:id"
+	taskRestoreURL   = "/task/:id/restore"
+	taskHistoryURL   = "/task/:id/history"
+	trashURL         = "/trash"
+	tasksURL         = "/tasks"
+	tasksBulkURL     = "/tasks/bulk"
+	tasksSearchURL   = "/tasks/search"
 )
 
+// maxBulkItems bounds a bulk request, which runs in a single transaction.
+const maxBulkItems = 1000
+
 type Handler struct {
 	log         logger.Logger
 	taskService Service
-	cache       *cache.Cache
+	cache       cache.Cache
 }
 
-func NewHandler(log logger.Logger, taskService Service, cache *cache.Cache) handler.Hand {
+func NewHandler(log logger.Logger, taskService Service, cache cache.Cache) handler.Hand {
 	return &Handler{
 		log:         log,
 		taskService: taskService,
@@ -39,12 +49,20 @@ func NewHandler(log logger.Logger, taskService Service, cache *cache.Cache) hand
 func (h *Handler) Register(router *httprouter.Router) {
 	router.HandlerFunc(http.MethodGet, taskIdURL, h.GetTaskById)
 	router.HandlerFunc(http.MethodGet, taskAllURL, h.FindAllTasks)
+	router.HandlerFunc(http.MethodGet, tasksURL, h.FindTasks)
+	router.HandlerFunc(http.MethodGet, tasksSearchURL, h.SearchTasks)
 	router.HandlerFunc(http.MethodPost, taskAllStatusURL, h.FindAllStatusTasks)
 	router.HandlerFunc(http.MethodPost, taskAvailableURL, h.FindDateAllAvailableTask)
 	router.HandlerFunc(http.MethodPost, taskURL, h.CreateTask)
 	router.HandlerFunc(http.MethodPut, taskIdURL, h.UpdateTask)
 	router.HandlerFunc(http.MethodPatch, taskIdURL, h.PartiallyUpdateTask)
 	router.HandlerFunc(http.MethodDelete, taskIdURL, h.DeleteTask)
+	router.HandlerFunc(http.MethodGet, trashURL, h.FindDeletedTasks)
+	router.HandlerFunc(http.MethodPost, taskRestoreURL, h.RestoreTask)
+	router.HandlerFunc(http.MethodGet, taskHistoryURL, h.GetTaskHistory)
+	router.HandlerFunc(http.MethodPost, tasksBulkURL, h.CreateTasks)
+	router.HandlerFunc(http.MethodPatch, tasksBulkURL, h.PartiallyUpdateTasks)
+	router.HandlerFunc(http.MethodDelete, tasksBulkURL, h.DeleteTasks)
 }
 
 // @Summary Создать задачу
@@ -63,6 +81,7 @@ func (h *Handler) Register(router *httprouter.Router) {
 //	}
 //
 // @Param input body CreateTask true "Данные для создания задачи"
+// @Param X-Actor header string false "Автор изменения для истории задачи"
 // @Success 201 {object} Task
 // @Router /task [post]
 func (h *Handler) CreateTask(w http.ResponseWriter, r *http.Request) {
@@ -76,12 +95,17 @@ func (h *Handler) CreateTask(w http.ResponseWriter, r *http.Request) {
 	}
 	h.log.Info("Input: ", input)
 
-	task, err := h.taskService.Create(r.Context(), &input)
+	task, err := h.taskService.Create(actorContext(r), &input)
 	if err != nil {
+		if errors.Is(err, apperror.ErrUnavailable) {
+			response.ServiceUnavailable(w, err.Error())
+			return
+		}
 		response.InternalError(w, fmt.Sprintf("cannot create task: %v", err), "")
 		return
 	}
 
+	setLastWrite(w)
 	response.JSON(w, http.StatusCreated, task)
 }
 
@@ -90,6 +114,8 @@ func (h *Handler) CreateTask(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param id path int true "Идентификатор задачи"
+// @Param If-None-Match header string false "ETag, полученный ранее; 304, если задача не изменилась"
+// @Param If-Modified-Since header string false "Last-Modified, полученный ранее"
 // @Success 200 {object} Task
 // @Router /task/{id} [get]
 func (h *Handler) GetTaskById(w http.ResponseWriter, r *http.Request) {
@@ -102,9 +128,15 @@ func (h *Handler) GetTaskById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cacheTask, ok := h.cache.Task[id]
+	cacheTask, ok := h.cache.Get(id)
 	if ok {
 		h.log.Info("GOT TASK FROM CACHE BY ID")
+		etag := taskETag(cacheTask.Version)
+		setValidators(w, etag, cacheTask.UpdatedAt)
+		if notModified(r, etag, cacheTask.UpdatedAt) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
 		response.JSON(w, http.StatusOK, cacheTask)
 		return
 	}
@@ -115,10 +147,21 @@ func (h *Handler) GetTaskById(w http.ResponseWriter, r *http.Request) {
 			response.NotFound(w)
 			return
 		}
+		if errors.Is(err, apperror.ErrUnavailable) {
+			response.ServiceUnavailable(w, err.Error())
+			return
+		}
 		h.log.Error(err)
 		response.InternalError(w, err.Error(), "")
 		return
 	}
+	cacheIfLaterVersion(h.cache, task)
+	etag := taskETag(task.Version)
+	setValidators(w, etag, task.UpdatedAt)
+	if notModified(r, etag, task.UpdatedAt) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 	response.JSON(w, http.StatusOK, task)
 }
 
@@ -126,35 +169,46 @@ func (h *Handler) GetTaskById(w http.ResponseWriter, r *http.Request) {
 // @Description Получает список всех задач
 // @Accept json
 // @Produce json
+// @Param If-None-Match header string false "ETag, полученный ранее; 304, если список не изменился"
+// @Param If-Modified-Since header string false "Last-Modified, полученный ранее"
+// @Param limit query int false "Размер страницы, до 1000, по умолчанию 100"
+// @Param cursor query string false "Курсор следующей страницы из заголовка Link"
 // @Success 200 {array} Task
-// @Router /tasks [get]
+// @Header 200 {string} Link "Ссылка на следующую страницу (rel=next)"
+// @Router /task_all [get]
 func (h *Handler) FindAllTasks(w http.ResponseWriter, r *http.Request) {
 	h.log.Info("HANDLER: GET ALL TASKS")
 
-	cacheTasks := make([]*model.Task, 0)
-	for _, task := range h.cache.Task {
-		cacheTasks = append(cacheTasks, task)
+	page, err := readPage(r)
+	if err != nil {
+		response.BadRequest(w, err.Error(), "")
+		return
 	}
-	if len(cacheTasks) > 0 {
-		sort.Slice(cacheTasks, func(i, j int) bool {
-			return cacheTasks[i].ID < cacheTasks[j].ID
-		})
+
+	if h.cache.Complete() {
+		// The validators are read first: should the cache change before the
+		// list is taken, they describe an older list and only cost a 200.
+		etag, modified := listETag(h.cache.Generation()), h.cache.LastModified()
+		setValidators(w, etag, modified)
+		if notModified(r, etag, modified) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
 		h.log.Info("GOT TASKS FROM CACHE")
-		response.JSON(w, http.StatusOK, cacheTasks)
+		h.cachedPage(w, r, page, h.cache.Page(page.After, page.Limit))
 		return
 	}
-	tasks, err := h.taskService.FindAll()
+	tasks, err := h.taskService.FindAll(readContext(r), page)
 	if err != nil {
+		if errors.Is(err, apperror.ErrUnavailable) {
+			response.ServiceUnavailable(w, err.Error())
+			return
+		}
 		response.BadRequest(w, err.Error(), "")
 		return
 	}
 
-	if len(*tasks) == 0 {
-		response.NotFound(w)
-		return
-	}
-
-	response.JSON(w, http.StatusOK, tasks)
+	h.storedPage(w, r, page, *tasks)
 }
 
 // @Summary Получить все задачи с определенным статусом
@@ -162,85 +216,186 @@ func (h *Handler) FindAllTasks(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param status query string true "Запрос на получение задач с определенным статусом"
+// @Param limit query int false "Размер страницы, до 1000, по умолчанию 100"
+// @Param cursor query string false "Курсор следующей страницы из заголовка X-Next-Cursor; тело запроса повторяется"
 // @Success 200 {array} Task
+// @Header 200 {string} X-Next-Cursor "Курсор следующей страницы"
 // @Router /tasks/status [post]
 func (h *Handler) FindAllStatusTasks(w http.ResponseWriter, r *http.Request) {
 	h.log.Info("HANDLER: GET ALL AVAILABLE STATUS TASKS")
+	page, err := readPage(r)
+	if err != nil {
+		response.BadRequest(w, err.Error(), "")
+		return
+	}
 	var input Task
-	if err := response.ReadJSON(w, r, &input); err != nil {
+	if err = response.ReadJSON(w, r, &input); err != nil {
 		response.BadRequest(w, err.Error(), apperror.ErrInvalidRequestBody.Error())
 		return
 	}
 	status := input.Status
-	cachedTasks := make([]*model.Task, 0)
-	for _, task := range h.cache.Task {
-		if task.Status == status {
-			cachedTasks = append(cachedTasks, task)
-		}
-	}
-	if len(cachedTasks) > 0 {
-		sort.Slice(cachedTasks, func(i, j int) bool {
-			return cachedTasks[i].ID < cachedTasks[j].ID
-		})
+	if h.cache.Complete() {
 		h.log.Info("GOT STATUS TASKS FROM CACHE")
-		response.JSON(w, http.StatusOK, cachedTasks)
+		h.cachedPage(w, r, page, h.cache.ByStatus(status, page.After, page.Limit))
 		return
 	}
 
-	tasks, err := h.taskService.FindAllStatus(r.Context(), status)
+	tasks, err := h.taskService.FindAllStatus(readContext(r), status, page)
 	if err != nil {
+		if errors.Is(err, apperror.ErrUnavailable) {
+			response.ServiceUnavailable(w, err.Error())
+			return
+		}
 		response.BadRequest(w, err.Error(), "")
 		return
 	}
-	if len(*tasks) == 0 {
-		response.NotFound(w)
-		return
-	}
-	response.JSON(w, http.StatusOK, tasks)
+	h.storedPage(w, r, page, *tasks)
 }
 
 // @Summary Получить все задачи по определенной дате
-// @Description Получает список всех доступных задач по заданной дате
+// @Description Получает задачи с заданным статусом за календарный день, неделю или месяц, которым принадлежит date, либо за интервал от from до to (to не включается). Календарь и время без смещения читаются в часовом поясе time_zone, по умолчанию UTC.
 // @Accept json
 // @Produce json
-// @Param date query string true "Запрос на получение задач с определенной датой и статусом"
+// @Param input body DateRangeQuery true "Дата или интервал, часовой пояс и статус задач"
+// @Param limit query int false "Размер страницы, до 1000, по умолчанию 100"
+// @Param cursor query string false "Курсор следующей страницы из заголовка X-Next-Cursor; тело запроса повторяется"
 // @Success 200 {array} Task
+// @Header 200 {string} X-Next-Cursor "Курсор следующей страницы"
 // @Router /tasks/date [post]
 func (h *Handler) FindDateAllAvailableTask(w http.ResponseWriter, r *http.Request) {
 	h.log.Info("HANDLER: GET ALL AVAILABLE DATE TASKS")
-	var input Task
-	if err := response.ReadJSON(w, r, &input); err != nil {
+	page, err := readPage(r)
+	if err != nil {
+		response.BadRequest(w, err.Error(), "")
+		return
+	}
+	var input DateRangeQuery
+	if err = response.ReadJSON(w, r, &input); err != nil {
 		response.BadRequest(w, err.Error(), apperror.ErrInvalidRequestBody.Error())
 		return
 	}
-	date := input.Date
+	dates, err := input.Range()
+	if err != nil {
+		response.BadRequest(w, err.Error(), "")
+		return
+	}
 	status := input.Status
 
-	cachedTasks := make([]*model.Task, 0)
-	for _, task := range h.cache.Task {
-		if task.Date == date && task.Status == status {
-			cachedTasks = append(cachedTasks, task)
-		}
-	}
-	if len(cachedTasks) > 0 {
-		sort.Slice(cachedTasks, func(i, j int) bool {
-			return cachedTasks[i].ID < cachedTasks[j].ID
-		})
+	if h.cache.Complete() {
 		h.log.Info("GOT STATUS TASKS FROM CACHE")
-		response.JSON(w, http.StatusOK, cachedTasks)
+		h.cachedPage(w, r, page, h.cache.ByDateRange(dates.From, dates.To, status, page.After, page.Limit))
+		return
+	}
+
+	tasks, err := h.taskService.FindDateAllAvailable(readContext(r), dates, status, page)
+	if err != nil {
+		if errors.Is(err, apperror.ErrUnavailable) {
+			response.ServiceUnavailable(w, err.Error())
+			return
+		}
+		response.BadRequest(w, err.Error(), "")
 		return
 	}
+	h.storedPage(w, r, page, *tasks)
+}
 
-	tasks, err := h.taskService.FindDateAllAvailable(r.Context(), date, status)
+// @Summary Найти задачи
+// @Description Получает задачи, отвечающие всем условиям filter, в порядке sort. Условие имеет вид поле:оператор:значение; поля id, title, description, status, date, created_at, updated_at; операторы eq, ne, lt, lte, gt, gte и contains для строк. Время задается в RFC 3339.
+// @Accept json
+// @Produce json
+// @Param filter query []string false "Условия, например status:eq:false или date:gte:2023-09-01T00:00:00Z" collectionFormat(multi)
+// @Param sort query string false "Ключи сортировки через запятую, - для обратного порядка, например -date,title"
+// @Param limit query int false "Размер страницы, до 1000, по умолчанию 100"
+// @Param cursor query string false "Курсор следующей страницы из заголовка Link"
+// @Success 200 {array} Task
+// @Header 200 {string} Link "Ссылка на следующую страницу (rel=next)"
+// @Router /tasks [get]
+func (h *Handler) FindTasks(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("HANDLER: FIND TASKS")
+
+	q, err := ParseQuery(r.URL.Query())
 	if err != nil {
 		response.BadRequest(w, err.Error(), "")
 		return
 	}
 
-	if len(*tasks) == 0 {
-		response.NotFound(w)
+	if h.cache.Complete() {
+		h.log.Info("GOT QUERIED TASKS FROM CACHE")
+		tasks := q.Apply(h.cache.List())
+		if len(tasks) == q.Limit {
+			setNextPage(w, r, q.Limit, q.Cursor(tasks[len(tasks)-1]))
+		}
+		response.JSON(w, http.StatusOK, tasks)
+		return
+	}
+
+	tasks, err := h.taskService.FindByQuery(readContext(r), q)
+	if err != nil {
+		if errors.Is(err, apperror.ErrUnavailable) {
+			response.ServiceUnavailable(w, err.Error())
+			return
+		}
+		response.InternalError(w, err.Error(), "wrong on the server")
+		return
+	}
+	if len(*tasks) == q.Limit {
+		last := (*tasks)[len(*tasks)-1]
+		setNextPage(w, r, q.Limit, q.Cursor(toModel(&last)))
+	}
+	response.JSON(w, http.StatusOK, tasks)
+}
+
+// @Summary Полнотекстовый поиск задач
+// @Description Ищет задачи по словам в заголовке и описании на русском и английском, начиная с лучших совпадений. Заголовок и фрагмент описания отдаются как HTML: текст экранирован, совпавшие слова выделены тегами <b>.
+// @Accept json
+// @Produce json
+// @Param q query string true "Поисковый запрос; поддерживаются кавычки, or и -слово"
+// @Param limit query int false "Число результатов, до 100, по умолчанию 20"
+// @Success 200 {array} SearchResult
+// @Router /tasks/search [get]
+func (h *Handler) SearchTasks(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("HANDLER: SEARCH TASKS")
+
+	text := strings.TrimSpace(r.URL.Query().Get("q"))
+	if text == "" {
+		response.BadRequest(w, "q is required", "")
+		return
+	}
+	limit := searchDefaultLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > searchMaxLimit {
+			response.BadRequest(w, fmt.Sprintf("limit must be between 1 and %d", searchMaxLimit), "")
+			return
+		}
+		limit = n
+	}
+
+	results, err := h.taskService.Search(readContext(r), text, limit)
+	if err != nil {
+		if errors.Is(err, apperror.ErrUnavailable) {
+			response.ServiceUnavailable(w, err.Error())
+			return
+		}
+		response.InternalError(w, err.Error(), "wrong on the server")
 		return
 	}
+	response.JSON(w, http.StatusOK, results)
+}
+
+// cachedPage answers with a page read from the cache.
+func (h *Handler) cachedPage(w http.ResponseWriter, r *http.Request, page Page, tasks []*model.Task) {
+	if page.Limit > 0 && len(tasks) == page.Limit {
+		setNextPage(w, r, page.Limit, encodeCursor(tasks[len(tasks)-1].ID))
+	}
+	response.JSON(w, http.StatusOK, tasks)
+}
+
+// storedPage answers with a page read from the storage.
+func (h *Handler) storedPage(w http.ResponseWriter, r *http.Request, page Page, tasks []Task) {
+	if page.Limit > 0 && len(tasks) == page.Limit {
+		setNextPage(w, r, page.Limit, encodeCursor(tasks[len(tasks)-1].ID))
+	}
 	response.JSON(w, http.StatusOK, tasks)
 }
 
@@ -250,6 +405,8 @@ func (h *Handler) FindDateAllAvailableTask(w http.ResponseWriter, r *http.Reques
 // @Produce json
 // @Param id path int true "Идентификатор задачи"
 // @Param input body Task true "Данные для обновления задачи"
+// @Param If-Match header string false "ETag задачи; при несовпадении версии вернется 412"
+// @Param X-Actor header string false "Автор изменения для истории задачи"
 // @Success 200 {object} Task
 // @Router /task/{id} [put]
 func (h *Handler) UpdateTask(w http.ResponseWriter, r *http.Request) {
@@ -262,20 +419,32 @@ func (h *Handler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var input Task
-	input.ID = id
-
 	if err = response.ReadJSON(w, r, &input); err != nil {
 		response.BadRequest(w, err.Error(), apperror.ErrInvalidRequestBody.Error())
 		return
 	}
+	input.ID = id
+	input.Version = ifMatchVersion(r)
 
-	task, err := h.taskService.Update(r.Context(), &input)
+	task, err := h.taskService.Update(actorContext(r), &input)
 	if err != nil {
 		if errors.Is(err, apperror.ErrEmptyString) {
 			response.NotFound(w)
 			return
 		}
+		if errors.Is(err, apperror.ErrVersionMismatch) {
+			response.PreconditionFailed(w, err.Error())
+			return
+		}
+		if errors.Is(err, apperror.ErrUnavailable) {
+			response.ServiceUnavailable(w, err.Error())
+			return
+		}
+		response.InternalError(w, err.Error(), "")
+		return
 	}
+	setETag(w, task.Version)
+	setLastWrite(w)
 	response.JSON(w, http.StatusOK, task)
 }
 
@@ -285,6 +454,8 @@ func (h *Handler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path int true "Идентификатор задачи"
 // @Param input body PartiallyUpdateTask true "Часть данных для обновления задачи, строки могут быть пустыми"
+// @Param If-Match header string false "ETag задачи; при несовпадении версии вернется 412"
+// @Param X-Actor header string false "Автор изменения для истории задачи"
 // @Success 200 {object} Task
 // @Router /task/{id} [patch]
 func (h *Handler) PartiallyUpdateTask(w http.ResponseWriter, r *http.Request) {
@@ -297,28 +468,41 @@ func (h *Handler) PartiallyUpdateTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var input PartiallyUpdateTask
-	input.ID = id
-
 	if err = response.ReadJSON(w, r, &input); err != nil {
 		response.BadRequest(w, err.Error(), apperror.ErrInvalidRequestBody.Error())
 		return
 	}
+	input.ID = id
+	input.Version = ifMatchVersion(r)
 
-	task, err := h.taskService.PartiallyUpdate(r.Context(), &input)
+	task, err := h.taskService.PartiallyUpdate(actorContext(r), &input)
 	if err != nil {
 		if errors.Is(err, apperror.ErrEmptyString) {
 			response.NotFound(w)
 			return
 		}
+		if errors.Is(err, apperror.ErrVersionMismatch) {
+			response.PreconditionFailed(w, err.Error())
+			return
+		}
+		if errors.Is(err, apperror.ErrUnavailable) {
+			response.ServiceUnavailable(w, err.Error())
+			return
+		}
+		response.InternalError(w, err.Error(), "")
+		return
 	}
+	setETag(w, task.Version)
+	setLastWrite(w)
 	response.JSON(w, http.StatusOK, task)
 }
 
 // @Summary Удалить задачу
-// @Description Удаляет задачу по заданному идентификатору
+// @Description Перемещает задачу с заданным идентификатором в корзину
 // @Accept json
 // @Produce json
 // @Param id path int true "Идентификатор задачи"
+// @Param X-Actor header string false "Автор изменения для истории задачи"
 // @Success 200 {string} string
 // @Router /task/{id} [delete]
 func (h *Handler) DeleteTask(w http.ResponseWriter, r *http.Request) {
@@ -330,14 +514,194 @@ func (h *Handler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.taskService.Delete(id)
+	err = h.taskService.Delete(actorContext(r), id)
 	if err != nil {
 		if errors.Is(err, apperror.ErrEmptyString) {
 			response.NotFound(w)
 			return
 		}
+		if errors.Is(err, apperror.ErrUnavailable) {
+			response.ServiceUnavailable(w, err.Error())
+			return
+		}
 		response.InternalError(w, err.Error(), "wrong on the server")
 		return
 	}
+	setLastWrite(w)
 	response.JSON(w, http.StatusOK, "TASK DELETED")
 }
+
+// @Summary Получить задачи из корзины
+// @Description Получает список удаленных задач, начиная с удаленных последними
+// @Accept json
+// @Produce json
+// @Success 200 {array} Task
+// @Router /trash [get]
+func (h *Handler) FindDeletedTasks(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("HANDLER: GET DELETED TASKS")
+
+	tasks, err := h.taskService.Trash(readContext(r))
+	if err != nil {
+		if errors.Is(err, apperror.ErrUnavailable) {
+			response.ServiceUnavailable(w, err.Error())
+			return
+		}
+		response.InternalError(w, err.Error(), "wrong on the server")
+		return
+	}
+	response.JSON(w, http.StatusOK, tasks)
+}
+
+// @Summary Восстановить задачу
+// @Description Возвращает задачу из корзины
+// @Accept json
+// @Produce json
+// @Param id path int true "Идентификатор задачи"
+// @Param X-Actor header string false "Автор изменения для истории задачи"
+// @Success 200 {object} Task
+// @Router /task/{id}/restore [post]
+func (h *Handler) RestoreTask(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("HANDLER: RESTORE TASK")
+
+	id, err := handler.ReadIdParam64(r)
+	if err != nil {
+		response.BadRequest(w, err.Error(), "")
+		return
+	}
+
+	task, err := h.taskService.Restore(actorContext(r), id)
+	if err != nil {
+		if errors.Is(err, apperror.ErrEmptyString) {
+			response.NotFound(w)
+			return
+		}
+		if errors.Is(err, apperror.ErrUnavailable) {
+			response.ServiceUnavailable(w, err.Error())
+			return
+		}
+		response.InternalError(w, err.Error(), "wrong on the server")
+		return
+	}
+	setETag(w, task.Version)
+	setLastWrite(w)
+	response.JSON(w, http.StatusOK, task)
+}
+
+// @Summary Получить историю изменений задачи
+// @Description Получает изменения задачи по полям, начиная с создания. История сохраняется и после окончательного удаления задачи из корзины; для задач, созданных до появления истории, список пуст
+// @Accept json
+// @Produce json
+// @Param id path int true "Идентификатор задачи"
+// @Success 200 {array} HistoryEntry
+// @Router /task/{id}/history [get]
+func (h *Handler) GetTaskHistory(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("HANDLER: GET TASK HISTORY")
+
+	id, err := handler.ReadIdParam64(r)
+	if err != nil {
+		response.BadRequest(w, err.Error(), "")
+		return
+	}
+
+	entries, err := h.taskService.History(readContext(r), id)
+	if err != nil {
+		if errors.Is(err, apperror.ErrEmptyString) {
+			response.NotFound(w)
+			return
+		}
+		if errors.Is(err, apperror.ErrUnavailable) {
+			response.ServiceUnavailable(w, err.Error())
+			return
+		}
+		response.InternalError(w, err.Error(), "wrong on the server")
+		return
+	}
+	response.JSON(w, http.StatusOK, entries)
+}
+
+// @Summary Создать несколько задач
+// @Description Создает задачи одной транзакцией и возвращает результат по каждой
+// @Accept json
+// @Produce json
+// @Param input body []CreateTask true "Данные для создания задач"
+// @Param X-Actor header string false "Автор изменения для истории задачи"
+// @Success 200 {array} BulkResult
+// @Router /tasks/bulk [post]
+func (h *Handler) CreateTasks(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("HANDLER: CREATE TASKS")
+
+	var input []CreateTask
+	if !h.readBulk(w, r, &input, func() int { return len(input) }) {
+		return
+	}
+
+	results, err := h.taskService.CreateMany(actorContext(r), input)
+	h.bulkResponse(w, results, err)
+}
+
+// @Summary Частично обновить несколько задач
+// @Description Обновляет задачи одной транзакцией и возвращает результат по каждой; version задачи заменяет If-Match
+// @Accept json
+// @Produce json
+// @Param input body []BulkUpdateTask true "Идентификаторы и изменяемые поля задач"
+// @Param X-Actor header string false "Автор изменения для истории задачи"
+// @Success 200 {array} BulkResult
+// @Router /tasks/bulk [patch]
+func (h *Handler) PartiallyUpdateTasks(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("HANDLER: PARTIALLY UPDATE TASKS")
+
+	var input []BulkUpdateTask
+	if !h.readBulk(w, r, &input, func() int { return len(input) }) {
+		return
+	}
+
+	results, err := h.taskService.PartiallyUpdateMany(actorContext(r), input)
+	h.bulkResponse(w, results, err)
+}
+
+// @Summary Удалить несколько задач
+// @Description Перемещает задачи в корзину одной транзакцией и возвращает результат по каждой
+// @Accept json
+// @Produce json
+// @Param input body []int true "Идентификаторы задач"
+// @Param X-Actor header string false "Автор изменения для истории задачи"
+// @Success 200 {array} BulkResult
+// @Router /tasks/bulk [delete]
+func (h *Handler) DeleteTasks(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("HANDLER: DELETE TASKS")
+
+	var ids []int64
+	if !h.readBulk(w, r, &ids, func() int { return len(ids) }) {
+		return
+	}
+
+	results, err := h.taskService.DeleteMany(actorContext(r), ids)
+	h.bulkResponse(w, results, err)
+}
+
+// readBulk reads the items of a bulk request into dest and answers 400 when
+// there are none or too many.
+func (h *Handler) readBulk(w http.ResponseWriter, r *http.Request, dest interface{}, count func() int) bool {
+	if err := response.ReadJSON(w, r, dest); err != nil {
+		response.BadRequest(w, err.Error(), apperror.ErrInvalidRequestBody.Error())
+		return false
+	}
+	if n := count(); n == 0 || n > maxBulkItems {
+		response.BadRequest(w, fmt.Sprintf("a bulk request takes 1 to %d items", maxBulkItems), "")
+		return false
+	}
+	return true
+}
+
+func (h *Handler) bulkResponse(w http.ResponseWriter, results []BulkResult, err error) {
+	if err != nil {
+		if errors.Is(err, apperror.ErrUnavailable) {
+			response.ServiceUnavailable(w, err.Error())
+			return
+		}
+		response.InternalError(w, err.Error(), "wrong on the server")
+		return
+	}
+	setLastWrite(w)
+	response.JSON(w, http.StatusOK, results)
+}
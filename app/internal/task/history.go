@@ -0,0 +1,90 @@
+package task
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	HistoryCreate  = "create"
+	HistoryUpdate  = "update"
+	HistoryDelete  = "delete"
+	HistoryRestore = "restore"
+)
+
+// actorHeader names the caller on whose behalf a change is made. It is taken
+// as given: authentication is left to the proxy in front of the service.
+const actorHeader = "X-Actor"
+
+// @Example HistoryEntry
+// {
+// "id": 2, "task_id": 1, "op": "update",
+// "changes": [{"field": "status", "old": false, "new": true}],
+// "actor": "ivanov",
+// "changed_at": "2023-09-20T08:30:00Z"
+// }
+type HistoryEntry struct {
+	ID        int64         `json:"id" example:"2"`
+	TaskID    int64         `json:"task_id" example:"1"`
+	Op        string        `json:"op" example:"update"`
+	Changes   []FieldChange `json:"changes"`
+	Actor     string        `json:"actor,omitempty" example:"ivanov"`
+	ChangedAt time.Time     `json:"changed_at" example:"2023-09-20T08:30:00Z"`
+}
+
+// FieldChange is the value of one task field before and after a change. Old
+// is null for a created task.
+type FieldChange struct {
+	Field string      `json:"field" example:"status"`
+	Old   interface{} `json:"old" swaggertype:"string" example:"false"`
+	New   interface{} `json:"new" swaggertype:"string" example:"true"`
+}
+
+// diffTasks lists the fields that differ between old and new. With a nil old
+// every field is reported as set.
+func diffTasks(old, new *Task) []FieldChange {
+	if old == nil {
+		return []FieldChange{
+			{Field: "title", New: new.Title},
+			{Field: "description", New: new.Description},
+			{Field: "date", New: new.Date},
+			{Field: "status", New: new.Status},
+		}
+	}
+
+	changes := make([]FieldChange, 0)
+	if old.Title != new.Title {
+		changes = append(changes, FieldChange{Field: "title", Old: old.Title, New: new.Title})
+	}
+	if old.Description != new.Description {
+		changes = append(changes, FieldChange{Field: "description", Old: old.Description, New: new.Description})
+	}
+	if !old.Date.Equal(new.Date) {
+		changes = append(changes, FieldChange{Field: "date", Old: old.Date, New: new.Date})
+	}
+	if old.Status != new.Status {
+		changes = append(changes, FieldChange{Field: "status", Old: old.Status, New: new.Status})
+	}
+	return changes
+}
+
+type actorKey struct{}
+
+// WithActor records who makes the changes done with ctx.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func actorFrom(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// actorContext is the request context carrying the actor from actorHeader.
+func actorContext(r *http.Request) context.Context {
+	if actor := r.Header.Get(actorHeader); actor != "" {
+		return WithActor(r.Context(), actor)
+	}
+	return r.Context()
+}
@@ -0,0 +1,174 @@
+package task
+
+import (
+	"Sber/app/internal/apperror"
+	"Sber/app/internal/cache"
+	"Sber/app/pkg/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+// taskChannel is the channel the task_notify trigger publishes row changes to.
+const taskChannel = "task_changes"
+
+const (
+	listenMinBackoff = time.Second
+	listenMaxBackoff = 30 * time.Second
+)
+
+type taskChange struct {
+	Op string `json:"op"`
+	ID int64  `json:"id"`
+}
+
+/// Структура Listener применяющая к кэшу изменения, сделанные другими экземплярами \\\
+
+type Listener struct {
+	log     logger.Logger
+	pool    *pgxpool.Pool
+	storage Storage
+	cache   cache.Cache
+}
+
+func NewListener(pool *pgxpool.Pool, storage Storage, cache cache.Cache) *Listener {
+	return &Listener{
+		log:     logger.GetLogger(),
+		pool:    pool,
+		storage: storage,
+		cache:   cache,
+	}
+}
+
+// Run listens for task changes until ctx is cancelled. When the connection
+// is lost it reconnects with exponential backoff and resynchronizes the cache,
+// because notifications sent while disconnected are not redelivered.
+func (l *Listener) Run(ctx context.Context) {
+	backoff := listenMinBackoff
+	resync := false
+	for {
+		subscribed, err := l.listen(ctx, resync)
+		if ctx.Err() != nil {
+			l.log.Info("LISTENER: stopped")
+			return
+		}
+		if subscribed {
+			backoff = listenMinBackoff
+		}
+		resync = true
+
+		l.log.Errorf("LISTENER: %v, reconnecting in %v", err, backoff)
+		select {
+		case <-ctx.Done():
+			l.log.Info("LISTENER: stopped")
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > listenMaxBackoff {
+			backoff = listenMaxBackoff
+		}
+	}
+}
+
+func (l *Listener) listen(ctx context.Context, resync bool) (bool, error) {
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("cannot acquire connection: %v", err)
+	}
+	defer func() {
+		// The connection is still subscribed, so it must not go back to the pool.
+		conn.Conn().Close(context.Background())
+		conn.Release()
+	}()
+
+	if _, err = conn.Exec(ctx, "LISTEN "+taskChannel); err != nil {
+		return false, fmt.Errorf("cannot listen on %s: %v", taskChannel, err)
+	}
+	l.log.Infof("LISTENER: listening on %s", taskChannel)
+
+	if resync {
+		if err = l.resync(ctx); err != nil {
+			l.log.Errorf("LISTENER: failed to resync cache: %v", err)
+		}
+	}
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return true, fmt.Errorf("connection lost: %v", err)
+		}
+		if err = l.apply(ctx, notification); err != nil {
+			l.log.Errorf("LISTENER: failed to apply notification %q: %v", notification.Payload, err)
+		}
+	}
+}
+
+func (l *Listener) apply(ctx context.Context, notification *pgconn.Notification) error {
+	var change taskChange
+	if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil {
+		return err
+	}
+
+	switch change.Op {
+	case "DELETE":
+		l.cache.Delete(change.ID)
+	case "INSERT":
+		if l.cache.Complete() {
+			return l.refresh(ctx, change.ID)
+		}
+	case "UPDATE":
+		if _, cached := l.cache.Get(change.ID); cached || l.cache.Complete() {
+			return l.refresh(ctx, change.ID)
+		}
+	default:
+		return fmt.Errorf("unknown operation %q", change.Op)
+	}
+	return nil
+}
+
+// refresh reloads one task from the database into the cache.
+func (l *Listener) refresh(ctx context.Context, id int64) error {
+	task, err := l.storage.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, apperror.ErrEmptyString) {
+			l.cache.Delete(id)
+			return nil
+		}
+		return err
+	}
+	l.cache.Set(toModel(task))
+	return nil
+}
+
+// resync brings the cache up to date after changes may have been missed.
+func (l *Listener) resync(ctx context.Context) error {
+	if !l.cache.Complete() {
+		for _, cached := range l.cache.List() {
+			if err := l.refresh(ctx, cached.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tasks, err := l.storage.FindAll(WithPrimary(ctx), Page{})
+	if err != nil {
+		return err
+	}
+	live := make(map[int64]struct{}, len(tasks))
+	for i := range tasks {
+		live[tasks[i].ID] = struct{}{}
+		l.cache.Set(toModel(&tasks[i]))
+	}
+	for _, cached := range l.cache.List() {
+		if _, ok := live[cached.ID]; !ok {
+			l.cache.Delete(cached.ID)
+		}
+	}
+	l.log.Info("LISTENER: cache resynchronized")
+	return nil
+}
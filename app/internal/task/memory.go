@@ -0,0 +1,394 @@
+package task
+
+import (
+	"Sber/app/internal/apperror"
+	"Sber/app/internal/cache"
+	"Sber/app/internal/model"
+	"Sber/app/pkg/logger"
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ Storage = &MemoryStorage{}
+
+/// Структура MemoryStorage хранящая задачи в памяти процесса \\\
+
+type MemoryStorage struct {
+	log    logger.Logger
+	txMu   sync.Mutex
+	mu     sync.RWMutex
+	tasks  map[int64]Task
+	lastID int64
+	cache  cacheWriter
+
+	history       map[int64][]HistoryEntry
+	lastHistoryID int64
+}
+
+func NewMemoryStorage(cache cache.Cache, cachePolicy string) Storage {
+	return &MemoryStorage{
+		log:     logger.GetLogger(),
+		tasks:   make(map[int64]Task),
+		history: make(map[int64][]HistoryEntry),
+		cache:   newCacheWriter(cache, cachePolicy),
+	}
+}
+
+// Begin serializes units of work, so the steps of one are not interleaved
+// with those of another. Writes are not undone by Rollback.
+func (m *MemoryStorage) Begin(ctx context.Context) (context.Context, Tx, error) {
+	m.txMu.Lock()
+	return ctx, &memoryTx{unlock: m.txMu.Unlock}, nil
+}
+
+func (m *MemoryStorage) Create(ctx context.Context, task *Task) (*Task, error) {
+	m.log.Info("MEMORY: CREATE TASK")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastID++
+	task.ID = m.lastID
+	task.CreatedAt = time.Now()
+	task.UpdatedAt = task.CreatedAt
+	task.Version = 1
+	m.tasks[task.ID] = *task
+
+	m.cache.created(task)
+
+	return task, nil
+}
+
+func (m *MemoryStorage) FindById(ctx context.Context, id int64) (*Task, error) {
+	m.log.Info("MEMORY: GET TASK BY ID")
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	task, ok := m.tasks[id]
+	if !ok || task.DeletedAt != nil {
+		return nil, apperror.ErrEmptyString
+	}
+	return &task, nil
+}
+
+func (m *MemoryStorage) FindAll(ctx context.Context, page Page) ([]Task, error) {
+	m.log.Info("MEMORY: GET ALL TASKS")
+
+	return page.tasks(m.filter(func(task *Task) bool {
+		return true
+	})), nil
+}
+
+func (m *MemoryStorage) FindAllStatus(ctx context.Context, status bool, page Page) ([]Task, error) {
+	m.log.Info("MEMORY: GET ALL AVAILABLE STATUS TASKS")
+
+	return page.tasks(m.filter(func(task *Task) bool {
+		return task.Status == status
+	})), nil
+}
+
+func (m *MemoryStorage) FindDateAllAvailable(ctx context.Context, dates DateRange, status bool, page Page) ([]Task, error) {
+	m.log.Info("MEMORY: GET ALL AVAILABLE DATE TASKS")
+
+	return page.tasks(m.filter(func(task *Task) bool {
+		return dates.Contains(task.Date) && task.Status == status
+	})), nil
+}
+
+func (m *MemoryStorage) FindByQuery(ctx context.Context, q *Query) ([]Task, error) {
+	m.log.Info("MEMORY: FIND TASKS BY QUERY")
+
+	live := m.filter(func(task *Task) bool {
+		return true
+	})
+	models := make([]*model.Task, 0, len(live))
+	for i := range live {
+		models = append(models, toModel(&live[i]))
+	}
+	tasks := make([]Task, 0)
+	for _, task := range q.Apply(models) {
+		tasks = append(tasks, *fromModel(task))
+	}
+	return tasks, nil
+}
+
+func (m *MemoryStorage) Search(ctx context.Context, text string, limit int) ([]SearchResult, error) {
+	m.log.Info("MEMORY: SEARCH TASKS")
+
+	terms := searchTerms(text)
+	results := make([]SearchResult, 0)
+	if len(terms) == 0 {
+		return results, nil
+	}
+	for _, task := range m.filter(func(task *Task) bool { return true }) {
+		rank, ok := rankTerms(&task, terms)
+		if !ok {
+			continue
+		}
+		results = append(results, SearchResult{
+			Task:           task,
+			Rank:           rank,
+			TitleHighlight: highlight(task.Title, terms),
+			Snippet:        highlight(task.Description, terms),
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank > results[j].Rank
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func (m *MemoryStorage) Update(ctx context.Context, task *Task) (*Task, error) {
+	m.log.Info("MEMORY: UPDATE TASK")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, ok := m.tasks[task.ID]
+	if !ok || old.DeletedAt != nil {
+		return nil, apperror.ErrEmptyString
+	}
+	task.CreatedAt = old.CreatedAt
+	task.UpdatedAt = time.Now()
+	task.Version = old.Version + 1
+	m.tasks[task.ID] = *task
+
+	m.cache.updated(task)
+	return task, nil
+}
+
+func (m *MemoryStorage) PartiallyUpdate(ctx context.Context, task *PartiallyUpdateTask) (*Task, error) {
+	m.log.Info("MEMORY: PARTIALLY UPDATE TASK")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	updatedTask, ok := m.tasks[task.ID]
+	if !ok || updatedTask.DeletedAt != nil {
+		return nil, apperror.ErrEmptyString
+	}
+	// Like the SQL storage, a patch that sets nothing leaves the task as it is.
+	if task.Title == nil && task.Description == nil && task.Date == nil && task.Status == nil {
+		return &updatedTask, nil
+	}
+	if task.Title != nil {
+		updatedTask.Title = *task.Title
+	}
+	if task.Description != nil {
+		updatedTask.Description = *task.Description
+	}
+	if task.Date != nil {
+		updatedTask.Date = *task.Date
+	}
+	if task.Status != nil {
+		updatedTask.Status = *task.Status
+	}
+	updatedTask.UpdatedAt = time.Now()
+	updatedTask.Version++
+	m.tasks[task.ID] = updatedTask
+
+	m.cache.updated(&updatedTask)
+	return &updatedTask, nil
+}
+
+func (m *MemoryStorage) Delete(ctx context.Context, id int64) error {
+	m.log.Info("MEMORY: DELETE TASK")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	task, ok := m.tasks[id]
+	if !ok || task.DeletedAt != nil {
+		return apperror.ErrEmptyString
+	}
+	now := time.Now()
+	task.DeletedAt = &now
+	task.UpdatedAt = now
+	task.Version++
+	m.tasks[id] = task
+
+	m.cache.deleted(id)
+	return nil
+}
+
+func (m *MemoryStorage) FindByIds(ctx context.Context, ids []int64) ([]Task, error) {
+	m.log.Info("MEMORY: GET TASKS BY IDS")
+
+	wanted := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	return m.filter(func(task *Task) bool {
+		return wanted[task.ID]
+	}), nil
+}
+
+func (m *MemoryStorage) CreateMany(ctx context.Context, tasks []*Task) ([]*Task, error) {
+	for _, task := range tasks {
+		if _, err := m.Create(ctx, task); err != nil {
+			return nil, err
+		}
+	}
+	return tasks, nil
+}
+
+func (m *MemoryStorage) PartiallyUpdateMany(ctx context.Context, tasks []*PartiallyUpdateTask) ([]*Task, error) {
+	updatedTasks := make([]*Task, len(tasks))
+	for i, task := range tasks {
+		updatedTask, err := m.PartiallyUpdate(ctx, task)
+		if errors.Is(err, apperror.ErrEmptyString) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		updatedTasks[i] = updatedTask
+	}
+	return updatedTasks, nil
+}
+
+func (m *MemoryStorage) DeleteMany(ctx context.Context, ids []int64) ([]int64, error) {
+	deleted := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		err := m.Delete(ctx, id)
+		if errors.Is(err, apperror.ErrEmptyString) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, id)
+	}
+	return deleted, nil
+}
+
+func (m *MemoryStorage) FindDeleted(ctx context.Context) ([]Task, error) {
+	m.log.Info("MEMORY: GET DELETED TASKS")
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tasks := make([]Task, 0)
+	for _, task := range m.tasks {
+		if task.DeletedAt != nil {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if !tasks[i].DeletedAt.Equal(*tasks[j].DeletedAt) {
+			return tasks[i].DeletedAt.After(*tasks[j].DeletedAt)
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+	return tasks, nil
+}
+
+func (m *MemoryStorage) Restore(ctx context.Context, id int64) (*Task, error) {
+	m.log.Info("MEMORY: RESTORE TASK")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	task, ok := m.tasks[id]
+	if !ok || task.DeletedAt == nil {
+		return nil, apperror.ErrEmptyString
+	}
+	task.DeletedAt = nil
+	task.UpdatedAt = time.Now()
+	task.Version++
+	m.tasks[id] = task
+
+	m.cache.created(&task)
+	return &task, nil
+}
+
+func (m *MemoryStorage) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	m.log.Info("MEMORY: PURGE DELETED TASKS")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var purged int64
+	for id, task := range m.tasks {
+		if task.DeletedAt != nil && task.DeletedAt.Before(deletedBefore) {
+			delete(m.tasks, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (m *MemoryStorage) AddHistory(ctx context.Context, entries ...*HistoryEntry) error {
+	m.log.Info("MEMORY: ADD TASK HISTORY")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, entry := range entries {
+		m.lastHistoryID++
+		entry.ID = m.lastHistoryID
+		entry.ChangedAt = time.Now()
+		m.history[entry.TaskID] = append(m.history[entry.TaskID], *entry)
+	}
+	return nil
+}
+
+func (m *MemoryStorage) FindHistory(ctx context.Context, taskID int64) ([]HistoryEntry, error) {
+	m.log.Info("MEMORY: GET TASK HISTORY")
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append(make([]HistoryEntry, 0), m.history[taskID]...), nil
+}
+
+// filter returns copies of the matching tasks that are not in the trash,
+// ordered by ID.
+func (m *MemoryStorage) filter(match func(task *Task) bool) []Task {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tasks := make([]Task, 0)
+	for _, task := range m.tasks {
+		if task.DeletedAt == nil && match(&task) {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].ID < tasks[j].ID
+	})
+	return tasks
+}
+
+func toModel(task *Task) *model.Task {
+	return &model.Task{
+		ID:          task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		Date:        task.Date,
+		Status:      task.Status,
+		CreatedAt:   task.CreatedAt,
+		UpdatedAt:   task.UpdatedAt,
+		Version:     task.Version,
+	}
+}
+
+func fromModel(task *model.Task) *Task {
+	return &Task{
+		ID:          task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		Date:        task.Date,
+		Status:      task.Status,
+		CreatedAt:   task.CreatedAt,
+		UpdatedAt:   task.UpdatedAt,
+		Version:     task.Version,
+	}
+}
@@ -7,8 +7,6 @@ import (
 	context "context"
 
 	mock "github.com/stretchr/testify/mock"
-
-	time "time"
 )
 
 // Service is an autogenerated mock type for the Service type
@@ -42,13 +40,39 @@ func (_m *Service) Create(ctx context.Context, _a1 *task.CreateTask) (*task.Task
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: id
-func (_m *Service) Delete(id int64) error {
-	ret := _m.Called(id)
+// CreateMany provides a mock function with given fields: ctx, input
+func (_m *Service) CreateMany(ctx context.Context, input []task.CreateTask) ([]task.BulkResult, error) {
+	ret := _m.Called(ctx, input)
+
+	var r0 []task.BulkResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []task.CreateTask) ([]task.BulkResult, error)); ok {
+		return rf(ctx, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []task.CreateTask) []task.BulkResult); ok {
+		r0 = rf(ctx, input)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]task.BulkResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []task.CreateTask) error); ok {
+		r1 = rf(ctx, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: ctx, id
+func (_m *Service) Delete(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int64) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -56,25 +80,51 @@ func (_m *Service) Delete(id int64) error {
 	return r0
 }
 
-// FindAll provides a mock function with given fields:
-func (_m *Service) FindAll() (*[]task.Task, error) {
-	ret := _m.Called()
+// DeleteMany provides a mock function with given fields: ctx, ids
+func (_m *Service) DeleteMany(ctx context.Context, ids []int64) ([]task.BulkResult, error) {
+	ret := _m.Called(ctx, ids)
+
+	var r0 []task.BulkResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int64) ([]task.BulkResult, error)); ok {
+		return rf(ctx, ids)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []int64) []task.BulkResult); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]task.BulkResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int64) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindAll provides a mock function with given fields: ctx, page
+func (_m *Service) FindAll(ctx context.Context, page task.Page) (*[]task.Task, error) {
+	ret := _m.Called(ctx, page)
 
 	var r0 *[]task.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func() (*[]task.Task, error)); ok {
-		return rf()
+	if rf, ok := ret.Get(0).(func(context.Context, task.Page) (*[]task.Task, error)); ok {
+		return rf(ctx, page)
 	}
-	if rf, ok := ret.Get(0).(func() *[]task.Task); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context, task.Page) *[]task.Task); ok {
+		r0 = rf(ctx, page)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*[]task.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context, task.Page) error); ok {
+		r1 = rf(ctx, page)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -82,25 +132,25 @@ func (_m *Service) FindAll() (*[]task.Task, error) {
 	return r0, r1
 }
 
-// FindAllStatus provides a mock function with given fields: ctx, status
-func (_m *Service) FindAllStatus(ctx context.Context, status bool) (*[]task.Task, error) {
-	ret := _m.Called(ctx, status)
+// FindAllStatus provides a mock function with given fields: ctx, status, page
+func (_m *Service) FindAllStatus(ctx context.Context, status bool, page task.Page) (*[]task.Task, error) {
+	ret := _m.Called(ctx, status, page)
 
 	var r0 *[]task.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, bool) (*[]task.Task, error)); ok {
-		return rf(ctx, status)
+	if rf, ok := ret.Get(0).(func(context.Context, bool, task.Page) (*[]task.Task, error)); ok {
+		return rf(ctx, status, page)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, bool) *[]task.Task); ok {
-		r0 = rf(ctx, status)
+	if rf, ok := ret.Get(0).(func(context.Context, bool, task.Page) *[]task.Task); ok {
+		r0 = rf(ctx, status, page)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*[]task.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, bool) error); ok {
-		r1 = rf(ctx, status)
+	if rf, ok := ret.Get(1).(func(context.Context, bool, task.Page) error); ok {
+		r1 = rf(ctx, status, page)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -108,25 +158,51 @@ func (_m *Service) FindAllStatus(ctx context.Context, status bool) (*[]task.Task
 	return r0, r1
 }
 
-// FindDateAllAvailable provides a mock function with given fields: ctx, date, status
-func (_m *Service) FindDateAllAvailable(ctx context.Context, date time.Time, status bool) (*[]task.Task, error) {
-	ret := _m.Called(ctx, date, status)
+// FindByQuery provides a mock function with given fields: ctx, q
+func (_m *Service) FindByQuery(ctx context.Context, q *task.Query) (*[]task.Task, error) {
+	ret := _m.Called(ctx, q)
 
 	var r0 *[]task.Task
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, time.Time, bool) (*[]task.Task, error)); ok {
-		return rf(ctx, date, status)
+	if rf, ok := ret.Get(0).(func(context.Context, *task.Query) (*[]task.Task, error)); ok {
+		return rf(ctx, q)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, time.Time, bool) *[]task.Task); ok {
-		r0 = rf(ctx, date, status)
+	if rf, ok := ret.Get(0).(func(context.Context, *task.Query) *[]task.Task); ok {
+		r0 = rf(ctx, q)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*[]task.Task)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, time.Time, bool) error); ok {
-		r1 = rf(ctx, date, status)
+	if rf, ok := ret.Get(1).(func(context.Context, *task.Query) error); ok {
+		r1 = rf(ctx, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindDateAllAvailable provides a mock function with given fields: ctx, dates, status, page
+func (_m *Service) FindDateAllAvailable(ctx context.Context, dates task.DateRange, status bool, page task.Page) (*[]task.Task, error) {
+	ret := _m.Called(ctx, dates, status, page)
+
+	var r0 *[]task.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, task.DateRange, bool, task.Page) (*[]task.Task, error)); ok {
+		return rf(ctx, dates, status, page)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, task.DateRange, bool, task.Page) *[]task.Task); ok {
+		r0 = rf(ctx, dates, status, page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*[]task.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, task.DateRange, bool, task.Page) error); ok {
+		r1 = rf(ctx, dates, status, page)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -160,6 +236,32 @@ func (_m *Service) GetById(ctx context.Context, id int64) (*task.Task, error) {
 	return r0, r1
 }
 
+// History provides a mock function with given fields: ctx, id
+func (_m *Service) History(ctx context.Context, id int64) (*[]task.HistoryEntry, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *[]task.HistoryEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*[]task.HistoryEntry, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *[]task.HistoryEntry); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*[]task.HistoryEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // PartiallyUpdate provides a mock function with given fields: ctx, _a1
 func (_m *Service) PartiallyUpdate(ctx context.Context, _a1 *task.PartiallyUpdateTask) (*task.Task, error) {
 	ret := _m.Called(ctx, _a1)
@@ -186,6 +288,110 @@ func (_m *Service) PartiallyUpdate(ctx context.Context, _a1 *task.PartiallyUpdat
 	return r0, r1
 }
 
+// PartiallyUpdateMany provides a mock function with given fields: ctx, input
+func (_m *Service) PartiallyUpdateMany(ctx context.Context, input []task.BulkUpdateTask) ([]task.BulkResult, error) {
+	ret := _m.Called(ctx, input)
+
+	var r0 []task.BulkResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []task.BulkUpdateTask) ([]task.BulkResult, error)); ok {
+		return rf(ctx, input)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []task.BulkUpdateTask) []task.BulkResult); ok {
+		r0 = rf(ctx, input)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]task.BulkResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []task.BulkUpdateTask) error); ok {
+		r1 = rf(ctx, input)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: ctx, id
+func (_m *Service) Restore(ctx context.Context, id int64) (*task.Task, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *task.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*task.Task, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *task.Task); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*task.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Search provides a mock function with given fields: ctx, text, limit
+func (_m *Service) Search(ctx context.Context, text string, limit int) (*[]task.SearchResult, error) {
+	ret := _m.Called(ctx, text, limit)
+
+	var r0 *[]task.SearchResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) (*[]task.SearchResult, error)); ok {
+		return rf(ctx, text, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) *[]task.SearchResult); ok {
+		r0 = rf(ctx, text, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*[]task.SearchResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, text, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Trash provides a mock function with given fields: ctx
+func (_m *Service) Trash(ctx context.Context) (*[]task.Task, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *[]task.Task
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (*[]task.Task, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *[]task.Task); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*[]task.Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Update provides a mock function with given fields: ctx, _a1
 func (_m *Service) Update(ctx context.Context, _a1 *task.Task) (*task.Task, error) {
 	ret := _m.Called(ctx, _a1)
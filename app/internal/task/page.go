@@ -0,0 +1,96 @@
+package task
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// defaultPageLimit is the page size when no limit is given.
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// Page selects a slice of a task list ordered by ID: at most Limit tasks with
+// IDs greater than After. The zero Page selects the whole list.
+type Page struct {
+	Limit int
+	After int64
+}
+
+// readPage reads the limit and cursor query parameters. Without a limit the
+// page holds defaultPageLimit tasks.
+func readPage(r *http.Request) (Page, error) {
+	page := Page{Limit: defaultPageLimit}
+	query := r.URL.Query()
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		after, err := decodeCursor(cursor)
+		if err != nil {
+			return Page{}, err
+		}
+		page.After = after
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxPageLimit {
+			return Page{}, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		page.Limit = n
+	}
+	return page, nil
+}
+
+// The cursor is opaque to clients, so that the ordering can change later
+// without breaking them.
+func encodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errInvalidCursor
+	}
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id < 0 {
+		return 0, errInvalidCursor
+	}
+	return id, nil
+}
+
+// setNextPage points the client to the next page. It is sent after every
+// full page, so the next one may turn out empty. A GET listing gets a Link
+// header. The filter of a POST listing is in the request body, which a link
+// cannot carry, so only the cursor is sent, in X-Next-Cursor, for the client
+// to repeat the request with.
+func setNextPage(w http.ResponseWriter, r *http.Request, limit int, cursor string) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("X-Next-Cursor", cursor)
+		return
+	}
+	next := *r.URL
+	query := next.Query()
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("cursor", cursor)
+	next.RawQuery = query.Encode()
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+}
+
+// tasks applies the page to tasks ordered by ID.
+func (p Page) tasks(tasks []Task) []Task {
+	start := 0
+	for start < len(tasks) && tasks[start].ID <= p.After {
+		start++
+	}
+	tasks = tasks[start:]
+	if p.Limit > 0 && len(tasks) > p.Limit {
+		tasks = tasks[:p.Limit]
+	}
+	return tasks
+}
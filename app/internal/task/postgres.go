@@ -3,114 +3,167 @@ package task
 import (
 	"Sber/app/internal/apperror"
 	"Sber/app/internal/cache"
-	"Sber/app/internal/model"
 	"Sber/app/pkg/logger"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"strings"
 	"time"
 )
 
 var _ Storage = &TaskStorage{}
 
+// taskColumns are the columns every task query selects, in the order
+// scanTask reads them. Naming them keeps queries working when columns are
+// added, and lets the statement cache reuse the plan.
+const taskColumns = `id, title, description, date, status, created_at, updated_at, version, deleted_at`
+
+func scanTask(row pgx.Row, task *Task) error {
+	return row.Scan(taskFields(task)...)
+}
+
+// taskFields are the scan destinations for taskColumns.
+func taskFields(task *Task) []interface{} {
+	return []interface{}{&task.ID, &task.Title, &task.Description, &task.Date, &task.Status, &task.CreatedAt, &task.UpdatedAt, &task.Version, &task.DeletedAt}
+}
+
 /// Структура DoctorStorage содержащая поля для работы с БД \\\
 
 type TaskStorage struct {
 	log            logger.Logger
-	conn           *pgx.Conn
+	pool           *pgxpool.Pool
+	replica        *pgxpool.Pool
 	requestTimeout time.Duration
-	cache          *cache.Cache
+	readYourWrites time.Duration
+	cache          cacheWriter
 }
 
-func NewStorage(storage *pgx.Conn, requestTimeout int, cache *cache.Cache) Storage {
+// NewStorage sends list queries to replica when it is not nil, except for
+// readYourWrites seconds after a write by the same client, as told by
+// WithLastWrite. Everything else goes to storage.
+func NewStorage(storage, replica *pgxpool.Pool, requestTimeout, readYourWrites int, cache cache.Cache, cachePolicy string) Storage {
 	return &TaskStorage{
 		log:            logger.GetLogger(),
-		conn:           storage,
+		pool:           storage,
+		replica:        replica,
 		requestTimeout: time.Duration(requestTimeout) * time.Second,
-		cache:          cache,
+		readYourWrites: time.Duration(readYourWrites) * time.Second,
+		cache:          newCacheWriter(cache, cachePolicy),
+	}
+}
+
+func (d *TaskStorage) Begin(ctx context.Context) (context.Context, Tx, error) {
+	tx, err := d.pool.Begin(ctx)
+	if err != nil {
+		err = fmt.Errorf("failed to begin transaction: %w", err)
+		d.log.Error(err)
+		return ctx, nil, err
 	}
+	pt := &pgTx{tx: tx}
+	return context.WithValue(ctx, txKey{}, pt), pt, nil
 }
 
-func (d *TaskStorage) Create(task *Task) (*Task, error) {
+// conn returns the transaction carried by ctx, or the pool outside of one.
+func (d *TaskStorage) conn(ctx context.Context) querier {
+	if tx := txFromContext(ctx); tx != nil {
+		return tx.tx
+	}
+	return d.pool
+}
+
+// onCommit runs fn once the transaction carried by ctx commits, or right
+// away outside of one.
+func (d *TaskStorage) onCommit(ctx context.Context, fn func()) {
+	if tx := txFromContext(ctx); tx != nil {
+		tx.afterCommit = append(tx.afterCommit, fn)
+		return
+	}
+	fn()
+}
+
+func (d *TaskStorage) Create(ctx context.Context, task *Task) (*Task, error) {
 	d.log.Info("POSTGRES: CREATE TASK")
 
-	ctx, cancel := context.WithTimeout(context.Background(), d.requestTimeout)
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
 	defer cancel()
 
-	row := d.conn.QueryRow(ctx,
+	row := d.conn(ctx).QueryRow(ctx,
 		`INSERT INTO Task (title, description, date, status)
 			 VALUES($1,$2,$3,$4) 
-			 RETURNING id`,
+			 RETURNING id, created_at, updated_at, version`,
 		task.Title, task.Description, task.Date, task.Status)
 
-	err := row.Scan(&task.ID)
+	err := row.Scan(&task.ID, &task.CreatedAt, &task.UpdatedAt, &task.Version)
 	if err != nil {
-		err = fmt.Errorf("failed to execute create task query: %v", err)
+		err = fmt.Errorf("failed to execute create task query: %w", err)
 		d.log.Error(err)
 		return nil, err
 	}
 
-	modelDelivery := &model.Task{
-		ID:          task.ID,
-		Title:       task.Title,
-		Description: task.Description,
-		Date:        task.Date,
-		Status:      task.Status,
-	}
-
-	d.cache.Task[task.ID] = modelDelivery
+	d.onCommit(ctx, func() {
+		d.cache.created(task)
+	})
 
 	return task, nil
 }
 
-func (d *TaskStorage) FindById(id int64) (*Task, error) {
+func (d *TaskStorage) FindById(ctx context.Context, id int64) (*Task, error) {
 	d.log.Info("POSTGRES: GET TASK BY ID")
 
-	ctx, cancel := context.WithTimeout(context.Background(), d.requestTimeout)
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
 	defer cancel()
 
-	row := d.conn.QueryRow(ctx,
-		`SELECT * FROM Task
-			 WHERE id = $1`, id)
+	query := `SELECT ` + taskColumns + ` FROM Task
+			 WHERE id = $1 AND deleted_at IS NULL`
+	if txFromContext(ctx) != nil {
+		// The task is read to be changed in the same transaction.
+		query += ` FOR UPDATE`
+	}
+	row := d.conn(ctx).QueryRow(ctx, query, id)
 
 	task := &Task{}
-	err := row.Scan(&task.ID, &task.Title, &task.Description, &task.Date, &task.Status)
+	err := scanTask(row, task)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, apperror.ErrEmptyString
 		}
-		err = fmt.Errorf("failed to execute find task by id query: %v", err)
+		err = fmt.Errorf("failed to execute find task by id query: %w", err)
 		d.log.Error(err)
 		return nil, err
 	}
 	return task, nil
 }
 
-func (d *TaskStorage) FindAll() ([]Task, error) {
+func (d *TaskStorage) FindAll(ctx context.Context, page Page) ([]Task, error) {
 	d.log.Info("POSTGRES: GET ALL TASKS")
 
-	ctx, cancel := context.WithTimeout(context.Background(), d.requestTimeout)
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
 	defer cancel()
 
-	rows, err := d.conn.Query(ctx,
-		`SELECT * FROM Task`)
+	rows, err := d.reader(ctx).Query(ctx,
+		`SELECT `+taskColumns+` FROM Task
+			 WHERE id > $1 AND deleted_at IS NULL
+			 ORDER BY id LIMIT NULLIF($2, 0)`,
+		page.After, page.Limit)
 	if err != nil {
-		err = fmt.Errorf("failed to SELLECT: %v", err)
+		err = fmt.Errorf("failed to SELLECT: %w", err)
 		d.log.Error(err)
 		return nil, err
 	}
+	defer rows.Close()
 
 	tasks := make([]Task, 0)
 	for rows.Next() {
 		var task Task
-		err = rows.Scan(&task.ID, &task.Title, &task.Description, &task.Date, &task.Status)
+		err = scanTask(rows, &task)
 		if err != nil {
 			if errors.Is(err, pgx.ErrNoRows) {
 				return nil, apperror.ErrEmptyString
 			}
-			err = fmt.Errorf("failed to execute find all tasks query: %v", err)
+			err = fmt.Errorf("failed to execute find all tasks query: %w", err)
 			d.log.Error(err)
 			return nil, err
 		}
@@ -123,31 +176,33 @@ func (d *TaskStorage) FindAll() ([]Task, error) {
 	return tasks, nil
 }
 
-func (d *TaskStorage) FindAllStatus(status bool) ([]Task, error) {
+func (d *TaskStorage) FindAllStatus(ctx context.Context, status bool, page Page) ([]Task, error) {
 	d.log.Info("POSTGRES: GET ALL AVAILABLE STATUS TASKS")
 
-	ctx, cancel := context.WithTimeout(context.Background(), d.requestTimeout)
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
 	defer cancel()
 
-	rows, err := d.conn.Query(ctx,
-		`SELECT * FROM Task
-			 WHERE status=$1`,
-		status)
+	rows, err := d.reader(ctx).Query(ctx,
+		`SELECT `+taskColumns+` FROM Task
+			 WHERE status=$1 AND id > $2 AND deleted_at IS NULL
+			 ORDER BY id LIMIT NULLIF($3, 0)`,
+		status, page.After, page.Limit)
 	if err != nil {
-		err = fmt.Errorf("failed to SELLECT: %v", err)
+		err = fmt.Errorf("failed to SELLECT: %w", err)
 		d.log.Error(err)
 		return nil, err
 	}
+	defer rows.Close()
 
 	tasks := make([]Task, 0)
 	for rows.Next() {
 		var task Task
-		err = rows.Scan(&task.ID, &task.Title, &task.Description, &task.Date, &task.Status)
+		err = scanTask(rows, &task)
 		if err != nil {
 			if errors.Is(err, pgx.ErrNoRows) {
 				return nil, apperror.ErrEmptyString
 			}
-			err = fmt.Errorf("failed to execute find all available status tasks query: %v", err)
+			err = fmt.Errorf("failed to execute find all available status tasks query: %w", err)
 			d.log.Error(err)
 			return nil, err
 		}
@@ -159,31 +214,33 @@ func (d *TaskStorage) FindAllStatus(status bool) ([]Task, error) {
 	return tasks, nil
 }
 
-func (d *TaskStorage) FindDateAllAvailable(date time.Time, status bool) ([]Task, error) {
+func (d *TaskStorage) FindDateAllAvailable(ctx context.Context, dates DateRange, status bool, page Page) ([]Task, error) {
 	d.log.Info("POSTGRES: GET ALL AVAILABLE DATE TASKS")
 
-	ctx, cancel := context.WithTimeout(context.Background(), d.requestTimeout)
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
 	defer cancel()
 
-	rows, err := d.conn.Query(ctx,
-		`SELECT * FROM Task
-			 WHERE date=$1 AND status=$2`,
-		date, status)
+	rows, err := d.reader(ctx).Query(ctx,
+		`SELECT `+taskColumns+` FROM Task
+			 WHERE date >= $1 AND date < $2 AND status=$3 AND id > $4 AND deleted_at IS NULL
+			 ORDER BY id LIMIT NULLIF($5, 0)`,
+		dates.From, dates.To, status, page.After, page.Limit)
 	if err != nil {
-		err = fmt.Errorf("failed to SELLECT: %v", err)
+		err = fmt.Errorf("failed to SELLECT: %w", err)
 		d.log.Error(err)
 		return nil, err
 	}
+	defer rows.Close()
 
 	tasks := make([]Task, 0)
 	for rows.Next() {
 		var task Task
-		err = rows.Scan(&task.ID, &task.Title, &task.Description, &task.Date, &task.Status)
+		err = scanTask(rows, &task)
 		if err != nil {
 			if errors.Is(err, pgx.ErrNoRows) {
 				return nil, apperror.ErrEmptyString
 			}
-			err = fmt.Errorf("failed to execute find all available tasks query: %v", err)
+			err = fmt.Errorf("failed to execute find all available tasks query: %w", err)
 			d.log.Error(err)
 			return nil, err
 		}
@@ -195,46 +252,65 @@ func (d *TaskStorage) FindDateAllAvailable(date time.Time, status bool) ([]Task,
 	return tasks, nil
 }
 
-func (d *TaskStorage) Update(task *Task) (*Task, error) {
+func (d *TaskStorage) Update(ctx context.Context, task *Task) (*Task, error) {
 	d.log.Info("POSTGRES: UPDATE TASK")
 
-	ctx, cancel := context.WithTimeout(context.Background(), d.requestTimeout)
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
 	defer cancel()
 
-	row, err := d.conn.Exec(ctx,
+	row := d.conn(ctx).QueryRow(ctx,
 		`UPDATE Task
 			SET title=$1, description=$2, date=$3, status=$4
-			WHERE id =$5`,
+			WHERE id =$5 AND deleted_at IS NULL
+			RETURNING `+taskColumns,
 		task.Title, task.Description, task.Date, task.Status, task.ID)
 
+	updatedTask := &Task{}
+	err := scanTask(row, updatedTask)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, apperror.ErrEmptyString
 		}
-		err = fmt.Errorf("failed to execute update task query: %v", err)
+		err = fmt.Errorf("failed to execute update task query: %w", err)
 		d.log.Error(err)
 		return nil, err
 	}
 
-	if row.RowsAffected() == 0 {
-		return nil, apperror.ErrEmptyString
+	d.onCommit(ctx, func() {
+		d.cache.updated(updatedTask)
+	})
+	return updatedTask, nil
+}
+
+func (d *TaskStorage) PartiallyUpdate(ctx context.Context, task *PartiallyUpdateTask) (*Task, error) {
+	d.log.Info("POSTGRES: PARTIALLY UPDATE TASK")
+
+	query, args := partialUpdateQuery(task)
+	if query == "" {
+		return d.FindById(ctx, task.ID)
 	}
 
-	if _, exists := d.cache.Task[task.ID]; exists {
-		d.cache.Task[task.ID] = &model.Task{
-			ID:          task.ID,
-			Title:       task.Title,
-			Description: task.Description,
-			Date:        task.Date,
-			Status:      task.Status,
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	updatedTask := &Task{}
+	err := scanTask(d.conn(ctx).QueryRow(ctx, query, args...), updatedTask)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperror.ErrEmptyString
 		}
+		return nil, fmt.Errorf("failed to update task partially: %w", err)
 	}
-	return task, nil
-}
 
-func (d *TaskStorage) PartiallyUpdate(task *PartiallyUpdateTask) (*Task, error) {
-	d.log.Info("POSTGRES: PARTIALLY UPDATE TASK")
+	d.onCommit(ctx, func() {
+		d.cache.updated(updatedTask)
+	})
+	return updatedTask, nil
+}
 
+// partialUpdateQuery builds the UPDATE for the fields set in task. The query
+// is empty when no field is set.
+func partialUpdateQuery(task *PartiallyUpdateTask) (string, []interface{}) {
 	values := make([]string, 0)
 	args := make([]interface{}, 0)
 	argId := 1
@@ -260,84 +336,513 @@ func (d *TaskStorage) PartiallyUpdate(task *PartiallyUpdateTask) (*Task, error)
 		argId++
 	}
 
+	if len(values) == 0 {
+		return "", nil
+	}
+
 	valuesQuery := strings.Join(values, ", ")
-	query := fmt.Sprintf("UPDATE Task  SET %s WHERE id = $%d", valuesQuery, argId)
+	query := fmt.Sprintf(`UPDATE Task  SET %s WHERE id = $%d AND deleted_at IS NULL
+			RETURNING %s`, valuesQuery, argId, taskColumns)
 	args = append(args, task.ID)
+	return query, args
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), d.requestTimeout)
+func (d *TaskStorage) Delete(ctx context.Context, id int64) error {
+	d.log.Info("POSTGRES: DELETE TASK")
+
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
 	defer cancel()
 
-	result, err := d.conn.Exec(ctx, query, args...)
+	result, err := d.conn(ctx).Exec(ctx,
+		`UPDATE Task SET deleted_at = now()
+			 WHERE id = $1 AND deleted_at IS NULL`, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update task partially: %v", err)
+		return fmt.Errorf("failed to delete task: %w", err)
 	}
 
 	if result.RowsAffected() == 0 {
-		return nil, apperror.ErrEmptyString
+		return apperror.ErrEmptyString
+	}
+
+	d.onCommit(ctx, func() {
+		d.cache.deleted(id)
+	})
+
+	return nil
+}
+
+func (d *TaskStorage) FindByQuery(ctx context.Context, q *Query) ([]Task, error) {
+	d.log.Info("POSTGRES: FIND TASKS BY QUERY")
+
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	query, args := compileQuery(q)
+	rows, err := d.reader(ctx).Query(ctx, query, args...)
+	if err != nil {
+		err = fmt.Errorf("failed to SELLECT: %w", err)
+		d.log.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := make([]Task, 0)
+	for rows.Next() {
+		var task Task
+		if err = scanTask(rows, &task); err != nil {
+			err = fmt.Errorf("failed to execute find tasks by query query: %w", err)
+			d.log.Error(err)
+			return nil, err
+		}
+		tasks = append(tasks, task)
 	}
 
-	updatedTask := &Task{
-		ID: task.ID,
+	if err = rows.Err(); err != nil {
+		return nil, err
 	}
+	return tasks, nil
+}
 
-	if _, exists := d.cache.Task[task.ID]; exists {
-		cachedTask := d.cache.Task[task.ID]
-		if cachedTask != nil {
-			if task.Title != nil {
-				updatedTask.Title = *task.Title
-				cachedTask.Title = *task.Title
-			} else {
-				updatedTask.Title = cachedTask.Title
-			}
-			if task.Description != nil {
-				updatedTask.Description = *task.Description
-				cachedTask.Description = *task.Description
-			} else {
-				updatedTask.Description = cachedTask.Description
-			}
-			if task.Date != nil {
-				updatedTask.Date = *task.Date
-				cachedTask.Date = *task.Date
-			} else {
-				updatedTask.Date = cachedTask.Date
+// Search matches the query against both the Russian and the English
+// configuration. Headlines use the Russian one, which stems words in Latin
+// letters with the English stemmer.
+func (d *TaskStorage) Search(ctx context.Context, text string, limit int) ([]SearchResult, error) {
+	d.log.Info("POSTGRES: SEARCH TASKS")
+
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	rows, err := d.reader(ctx).Query(ctx,
+		`SELECT `+taskColumns+`, ts_rank(search, query.q),
+			 ts_headline('russian', title, query.q, $3),
+			 ts_headline('russian', description, query.q, $4)
+			 FROM Task, (SELECT websearch_to_tsquery('russian', $1) || websearch_to_tsquery('english', $1) AS q) AS query
+			 WHERE search @@ query.q AND deleted_at IS NULL
+			 ORDER BY ts_rank(search, query.q) DESC, id
+			 LIMIT $2`,
+		text, limit, titleHeadline, snippetHeadline)
+	if err != nil {
+		err = fmt.Errorf("failed to SELLECT: %w", err)
+		d.log.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]SearchResult, 0)
+	for rows.Next() {
+		var result SearchResult
+		err = rows.Scan(append(taskFields(&result.Task), &result.Rank, &result.TitleHighlight, &result.Snippet)...)
+		if err != nil {
+			err = fmt.Errorf("failed to execute search tasks query: %w", err)
+			d.log.Error(err)
+			return nil, err
+		}
+		result.TitleHighlight = markHighlights(result.TitleHighlight)
+		result.Snippet = markHighlights(result.Snippet)
+		results = append(results, result)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+var sqlOps = map[string]string{
+	OpEq:  "=",
+	OpNe:  "<>",
+	OpLt:  "<",
+	OpLte: "<=",
+	OpGt:  ">",
+	OpGte: ">=",
+}
+
+// compileQuery turns a validated query into SQL. Field names come from
+// queryFields and are safe to splice in; every value is a parameter. Strings
+// are ordered with the "C" collation, byte by byte, like Query.Apply does.
+func compileQuery(q *Query) (string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	args := make([]interface{}, 0)
+	param := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	column := func(field string) string {
+		if queryFields[field] == stringField {
+			return field + ` COLLATE "C"`
+		}
+		return field
+	}
+
+	for _, c := range q.Conditions {
+		if c.Op == OpContains {
+			conditions = append(conditions, fmt.Sprintf("strpos(lower(%s), lower(%s)) > 0", c.Field, param(c.Value)))
+			continue
+		}
+		conditions = append(conditions, fmt.Sprintf("%s %s %s", c.Field, sqlOps[c.Op], param(c.Value)))
+	}
+
+	if q.After != nil {
+		// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ..., with < for descending keys.
+		alternatives := make([]string, 0, len(q.Sort))
+		for i, key := range q.Sort {
+			terms := make([]string, 0, i+1)
+			for j := 0; j < i; j++ {
+				terms = append(terms, fmt.Sprintf("%s = %s", column(q.Sort[j].Field), param(q.After[j])))
 			}
-			if task.Status != nil {
-				updatedTask.Status = *task.Status
-				cachedTask.Status = *task.Status
-			} else {
-				updatedTask.Status = cachedTask.Status
+			op := ">"
+			if key.Desc {
+				op = "<"
 			}
+			terms = append(terms, fmt.Sprintf("%s %s %s", column(key.Field), op, param(q.After[i])))
+			alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
 		}
+		conditions = append(conditions, "("+strings.Join(alternatives, " OR ")+")")
 	}
 
-	return updatedTask, nil
+	order := make([]string, 0, len(q.Sort))
+	for _, key := range q.Sort {
+		if key.Desc {
+			order = append(order, column(key.Field)+" DESC")
+		} else {
+			order = append(order, column(key.Field))
+		}
+	}
+
+	query := `SELECT ` + taskColumns + ` FROM Task
+			 WHERE ` + strings.Join(conditions, " AND ") + `
+			 ORDER BY ` + strings.Join(order, ", ") + `
+			 LIMIT ` + param(q.Limit)
+	return query, args
 }
 
-func (d *TaskStorage) Delete(id int64) error {
-	d.log.Info("POSTGRES: DELETE TASK")
+func (d *TaskStorage) FindDeleted(ctx context.Context) ([]Task, error) {
+	d.log.Info("POSTGRES: GET DELETED TASKS")
 
-	ctx, cancel := context.WithTimeout(context.Background(), d.requestTimeout)
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
 	defer cancel()
 
-	result, err := d.conn.Exec(ctx,
-		`DELETE FROM Task WHERE id = $1`, id)
+	rows, err := d.reader(ctx).Query(ctx,
+		`SELECT `+taskColumns+` FROM Task
+			 WHERE deleted_at IS NOT NULL
+			 ORDER BY deleted_at DESC, id`)
 	if err != nil {
-		return fmt.Errorf("failed to delete task: %v", err)
+		err = fmt.Errorf("failed to SELLECT: %w", err)
+		d.log.Error(err)
+		return nil, err
 	}
+	defer rows.Close()
 
-	if result.RowsAffected() == 0 {
-		return apperror.ErrEmptyString
+	tasks := make([]Task, 0)
+	for rows.Next() {
+		var task Task
+		err = scanTask(rows, &task)
+		if err != nil {
+			err = fmt.Errorf("failed to execute find deleted tasks query: %w", err)
+			d.log.Error(err)
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (d *TaskStorage) Restore(ctx context.Context, id int64) (*Task, error) {
+	d.log.Info("POSTGRES: RESTORE TASK")
+
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	row := d.conn(ctx).QueryRow(ctx,
+		`UPDATE Task SET deleted_at = NULL
+			WHERE id = $1 AND deleted_at IS NOT NULL
+			RETURNING `+taskColumns, id)
+
+	restoredTask := &Task{}
+	err := scanTask(row, restoredTask)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperror.ErrEmptyString
+		}
+		err = fmt.Errorf("failed to execute restore task query: %w", err)
+		d.log.Error(err)
+		return nil, err
+	}
+
+	d.onCommit(ctx, func() {
+		d.cache.created(restoredTask)
+	})
+	return restoredTask, nil
+}
+
+func (d *TaskStorage) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	d.log.Info("POSTGRES: PURGE DELETED TASKS")
+
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	result, err := d.conn(ctx).Exec(ctx,
+		`DELETE FROM Task WHERE deleted_at < $1`, deletedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted tasks: %w", err)
 	}
+	return result.RowsAffected(), nil
+}
+
+func (d *TaskStorage) AddHistory(ctx context.Context, entries ...*HistoryEntry) error {
+	d.log.Info("POSTGRES: ADD TASK HISTORY")
 
-	if _, exists := d.cache.Task[id]; exists {
-		delete(d.cache.Task, id)
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	batch := &pgx.Batch{}
+	for _, entry := range entries {
+		changes, err := json.Marshal(entry.Changes)
+		if err != nil {
+			return fmt.Errorf("cannot encode task changes: %w", err)
+		}
+		batch.Queue(`INSERT INTO task_history (task_id, op, changes, actor)
+			 VALUES ($1, $2, $3, NULLIF($4, ''))
+			 RETURNING id, changed_at`,
+			entry.TaskID, entry.Op, changes, entry.Actor)
 	}
 
+	results := d.conn(ctx).SendBatch(ctx, batch)
+	defer results.Close()
+	for _, entry := range entries {
+		if err := results.QueryRow().Scan(&entry.ID, &entry.ChangedAt); err != nil {
+			err = fmt.Errorf("failed to execute add task history query: %w", err)
+			d.log.Error(err)
+			return err
+		}
+	}
 	return nil
 }
 
-func CacheForTask(dbConn *pgx.Conn, cache *cache.Cache) error {
-	rows, err := dbConn.Query(context.Background(), `SELECT * FROM Task`)
+func (d *TaskStorage) FindHistory(ctx context.Context, taskID int64) ([]HistoryEntry, error) {
+	d.log.Info("POSTGRES: GET TASK HISTORY")
+
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	rows, err := d.reader(ctx).Query(ctx,
+		`SELECT id, task_id, op, changes, COALESCE(actor, ''), changed_at FROM task_history
+			 WHERE task_id = $1
+			 ORDER BY id`, taskID)
+	if err != nil {
+		err = fmt.Errorf("failed to SELLECT: %w", err)
+		d.log.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]HistoryEntry, 0)
+	for rows.Next() {
+		var entry HistoryEntry
+		var changes []byte
+		err = rows.Scan(&entry.ID, &entry.TaskID, &entry.Op, &changes, &entry.Actor, &entry.ChangedAt)
+		if err != nil {
+			err = fmt.Errorf("failed to execute find task history query: %w", err)
+			d.log.Error(err)
+			return nil, err
+		}
+		if err = json.Unmarshal(changes, &entry.Changes); err != nil {
+			return nil, fmt.Errorf("cannot decode task changes: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (d *TaskStorage) FindByIds(ctx context.Context, ids []int64) ([]Task, error) {
+	d.log.Info("POSTGRES: GET TASKS BY IDS")
+
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	query := `SELECT ` + taskColumns + ` FROM Task
+			 WHERE id = ANY($1) AND deleted_at IS NULL
+			 ORDER BY id`
+	if txFromContext(ctx) != nil {
+		query += ` FOR UPDATE`
+	}
+	rows, err := d.conn(ctx).Query(ctx, query, ids)
+	if err != nil {
+		err = fmt.Errorf("failed to SELLECT: %w", err)
+		d.log.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := make([]Task, 0, len(ids))
+	for rows.Next() {
+		var task Task
+		if err = scanTask(rows, &task); err != nil {
+			err = fmt.Errorf("failed to execute find tasks by ids query: %w", err)
+			d.log.Error(err)
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (d *TaskStorage) CreateMany(ctx context.Context, tasks []*Task) ([]*Task, error) {
+	d.log.Info("POSTGRES: CREATE TASKS")
+
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	batch := &pgx.Batch{}
+	for _, task := range tasks {
+		batch.Queue(`INSERT INTO Task (title, description, date, status)
+			 VALUES($1,$2,$3,$4)
+			 RETURNING id, created_at, updated_at, version`,
+			task.Title, task.Description, task.Date, task.Status)
+	}
+
+	results := d.conn(ctx).SendBatch(ctx, batch)
+	defer results.Close()
+	for _, task := range tasks {
+		if err := results.QueryRow().Scan(&task.ID, &task.CreatedAt, &task.UpdatedAt, &task.Version); err != nil {
+			err = fmt.Errorf("failed to execute create tasks query: %w", err)
+			d.log.Error(err)
+			return nil, err
+		}
+	}
+
+	d.onCommit(ctx, func() {
+		for _, task := range tasks {
+			d.cache.created(task)
+		}
+	})
+	return tasks, nil
+}
+
+func (d *TaskStorage) PartiallyUpdateMany(ctx context.Context, tasks []*PartiallyUpdateTask) ([]*Task, error) {
+	d.log.Info("POSTGRES: PARTIALLY UPDATE TASKS")
+
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	batch := &pgx.Batch{}
+	for _, task := range tasks {
+		query, args := partialUpdateQuery(task)
+		if query == "" {
+			query, args = `SELECT `+taskColumns+` FROM Task
+			 WHERE id = $1 AND deleted_at IS NULL`, []interface{}{task.ID}
+		}
+		batch.Queue(query, args...)
+	}
+
+	results := d.conn(ctx).SendBatch(ctx, batch)
+	defer results.Close()
+	updatedTasks := make([]*Task, len(tasks))
+	for i := range tasks {
+		updatedTask := &Task{}
+		err := scanTask(results.QueryRow(), updatedTask)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to update tasks partially: %w", err)
+		}
+		updatedTasks[i] = updatedTask
+	}
+
+	d.onCommit(ctx, func() {
+		for _, task := range updatedTasks {
+			if task != nil {
+				d.cache.updated(task)
+			}
+		}
+	})
+	return updatedTasks, nil
+}
+
+func (d *TaskStorage) DeleteMany(ctx context.Context, ids []int64) ([]int64, error) {
+	d.log.Info("POSTGRES: DELETE TASKS")
+
+	ctx, cancel := context.WithTimeout(ctx, d.requestTimeout)
+	defer cancel()
+
+	rows, err := d.conn(ctx).Query(ctx,
+		`UPDATE Task SET deleted_at = now()
+			 WHERE id = ANY($1) AND deleted_at IS NULL
+			 RETURNING id`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete tasks: %w", err)
+	}
+	defer rows.Close()
+
+	deleted := make([]int64, 0, len(ids))
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to delete tasks: %w", err)
+		}
+		deleted = append(deleted, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to delete tasks: %w", err)
+	}
+
+	d.onCommit(ctx, func() {
+		for _, id := range deleted {
+			d.cache.deleted(id)
+		}
+	})
+	return deleted, nil
+}
+
+// cacheTaskPage caches up to limit tasks with IDs greater than afterID, in
+// ID order, and returns how many were read and the last ID among them. A row
+// is skipped when the cache already holds a newer copy written meanwhile.
+func cacheTaskPage(ctx context.Context, dbPool *pgxpool.Pool, cache cache.Cache, afterID int64, limit int) (int, int64, error) {
+	rows, err := dbPool.Query(ctx,
+		`SELECT `+taskColumns+` FROM Task
+			 WHERE id > $1 AND deleted_at IS NULL
+			 ORDER BY id
+			 LIMIT $2`, afterID, limit)
+	if err != nil {
+		return 0, afterID, err
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		var task Task
+		err = scanTask(rows, &task)
+		if err != nil {
+			return n, afterID, err
+		}
+		cacheIfNewer(cache, &task)
+		afterID = task.ID
+		n++
+	}
+	return n, afterID, rows.Err()
+}
+
+// reconcileSkew widens the reconcile window: a transaction that commits after
+// a snapshot was taken may carry an updated_at older than the watermark.
+const reconcileSkew = time.Minute
+
+// reconcileCache brings a cache restored from a snapshot up to date: tasks
+// changed after watermark are reloaded and deleted tasks are dropped.
+func reconcileCache(ctx context.Context, dbPool *pgxpool.Pool, cache cache.Cache, watermark time.Time) error {
+	rows, err := dbPool.Query(ctx, `SELECT `+taskColumns+` FROM Task WHERE updated_at > $1 AND deleted_at IS NULL`, watermark.Add(-reconcileSkew))
 	if err != nil {
 		return err
 	}
@@ -345,19 +850,62 @@ func CacheForTask(dbConn *pgx.Conn, cache *cache.Cache) error {
 
 	for rows.Next() {
 		var task Task
-		err = rows.Scan(
-			&task.ID, &task.Title, &task.Description, &task.Date, &task.Status)
+		err = scanTask(rows, &task)
 		if err != nil {
 			return err
 		}
-		modelTask := &model.Task{
-			ID:          task.ID,
-			Title:       task.Title,
-			Description: task.Description,
-			Date:        task.Date,
-			Status:      task.Status,
+		cacheIfNewer(cache, &task)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	return dropDeleted(ctx, dbPool, cache)
+}
+
+// dropDeleted removes the cached tasks that are no longer in the database.
+func dropDeleted(ctx context.Context, dbPool *pgxpool.Pool, cache cache.Cache) error {
+	// Tasks cached after this point were written by this instance and exist.
+	known := cache.List()
+
+	rows, err := dbPool.Query(ctx, `SELECT id FROM Task WHERE deleted_at IS NULL`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	live := make(map[int64]struct{})
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return err
+		}
+		live[id] = struct{}{}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	for _, task := range known {
+		if _, ok := live[task.ID]; !ok {
+			cache.Delete(task.ID)
 		}
-		cache.Task[task.ID] = modelTask
 	}
 	return nil
 }
+
+func cacheIfNewer(cache cache.Cache, task *Task) {
+	if cached, ok := cache.Get(task.ID); ok && cached.UpdatedAt.After(task.UpdatedAt) {
+		return
+	}
+	cache.Set(toModel(task))
+}
+
+// cacheIfLaterVersion stores a task read from the storage unless the cache
+// already holds it at the same or a later version, written meanwhile.
+func cacheIfLaterVersion(cache cache.Cache, task *Task) {
+	if cached, ok := cache.Get(task.ID); ok && cached.Version >= task.Version {
+		return
+	}
+	cache.Set(toModel(task))
+}
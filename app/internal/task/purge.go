@@ -0,0 +1,57 @@
+package task
+
+import (
+	"Sber/app/pkg/logger"
+	"context"
+	"time"
+)
+
+/// Структура Purger периодически очищающая корзину \\\
+
+// Purger removes for good the tasks that have stayed in the trash longer than
+// the retention period.
+type Purger struct {
+	log       logger.Logger
+	storage   Storage
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewPurger(storage Storage, retention, interval time.Duration) *Purger {
+	return &Purger{
+		log:       logger.GetLogger(),
+		storage:   storage,
+		retention: retention,
+		interval:  interval,
+	}
+}
+
+// Run purges the trash right away and then every interval until ctx is
+// cancelled. With an interval that is not positive it purges only once.
+func (p *Purger) Run(ctx context.Context) {
+	if p.interval <= 0 {
+		p.Purge(ctx)
+		return
+	}
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		p.Purge(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Purger) Purge(ctx context.Context) {
+	purged, err := p.storage.Purge(ctx, time.Now().Add(-p.retention))
+	if err != nil {
+		p.log.Errorf("PURGE: %v", err)
+		return
+	}
+	if purged > 0 {
+		p.log.Infof("PURGE: removed %d tasks from the trash", purged)
+	}
+}
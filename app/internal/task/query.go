@@ -0,0 +1,354 @@
+package task
+
+import (
+	"Sber/app/internal/model"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query is a validated task list query: the tasks that meet every condition,
+// ordered by the sort keys, at most Limit of them, starting after the task
+// whose sort key values are After. The last sort key is always id, so the
+// order is total and pages never overlap.
+type Query struct {
+	Conditions []Condition
+	Sort       []SortKey
+	Limit      int
+	After      []interface{}
+}
+
+type Condition struct {
+	Field string
+	Op    string
+	Value interface{}
+}
+
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+const (
+	OpEq       = "eq"
+	OpNe       = "ne"
+	OpLt       = "lt"
+	OpLte      = "lte"
+	OpGt       = "gt"
+	OpGte      = "gte"
+	OpContains = "contains"
+)
+
+type fieldKind int
+
+const (
+	intField fieldKind = iota
+	stringField
+	boolField
+	timeField
+)
+
+// queryFields are the task fields a query can filter and sort on. The names
+// are also the column names.
+var queryFields = map[string]fieldKind{
+	"id":          intField,
+	"title":       stringField,
+	"description": stringField,
+	"status":      boolField,
+	"date":        timeField,
+	"created_at":  timeField,
+	"updated_at":  timeField,
+}
+
+var fieldOps = map[fieldKind][]string{
+	intField:    {OpEq, OpNe, OpLt, OpLte, OpGt, OpGte},
+	stringField: {OpEq, OpNe, OpContains},
+	boolField:   {OpEq, OpNe},
+	timeField:   {OpEq, OpNe, OpLt, OpLte, OpGt, OpGte},
+}
+
+// ParseQuery reads a query from the request parameters:
+//
+//   - filter=field:op:value, repeated for every condition, for example
+//     filter=status:eq:false or filter=date:gte:2023-09-01T00:00:00Z;
+//   - sort=key,key with a leading "-" for descending order, for example
+//     sort=-date,title;
+//   - limit and cursor, as for the other listings.
+func ParseQuery(values url.Values) (*Query, error) {
+	q := &Query{Limit: defaultPageLimit}
+
+	for _, filter := range values["filter"] {
+		parts := strings.SplitN(filter, ":", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("filter %q must look like field:op:value", filter)
+		}
+		field, op, raw := parts[0], parts[1], parts[2]
+		kind, ok := queryFields[field]
+		if !ok {
+			return nil, fmt.Errorf("unknown filter field %q", field)
+		}
+		if !allowedOp(kind, op) {
+			return nil, fmt.Errorf("operator %q cannot be used with field %q", op, field)
+		}
+		value, err := parseValue(kind, raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for field %q: %v", field, err)
+		}
+		q.Conditions = append(q.Conditions, Condition{Field: field, Op: op, Value: value})
+	}
+
+	seen := make(map[string]bool)
+	if spec := values.Get("sort"); spec != "" {
+		for _, key := range strings.Split(spec, ",") {
+			desc := strings.HasPrefix(key, "-")
+			field := strings.TrimPrefix(key, "-")
+			if _, ok := queryFields[field]; !ok {
+				return nil, fmt.Errorf("unknown sort field %q", field)
+			}
+			if seen[field] {
+				return nil, fmt.Errorf("sort field %q is given twice", field)
+			}
+			seen[field] = true
+			q.Sort = append(q.Sort, SortKey{Field: field, Desc: desc})
+		}
+	}
+	if !seen["id"] {
+		q.Sort = append(q.Sort, SortKey{Field: "id"})
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxPageLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		q.Limit = n
+	}
+	if cursor := values.Get("cursor"); cursor != "" {
+		after, err := q.decodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		q.After = after
+	}
+	return q, nil
+}
+
+func allowedOp(kind fieldKind, op string) bool {
+	for _, allowed := range fieldOps[kind] {
+		if op == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func parseValue(kind fieldKind, raw string) (interface{}, error) {
+	switch kind {
+	case intField:
+		return strconv.ParseInt(raw, 10, 64)
+	case boolField:
+		return strconv.ParseBool(raw)
+	case timeField:
+		return time.Parse(time.RFC3339Nano, raw)
+	}
+	return raw, nil
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	}
+	return value.(string)
+}
+
+// queryCursor is what a query cursor encodes. The sort is kept to reject a
+// cursor issued for another order.
+type queryCursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+}
+
+// Cursor returns the cursor of the page that starts after task.
+func (q *Query) Cursor(task *model.Task) string {
+	values := make([]string, 0, len(q.Sort))
+	for _, key := range q.Sort {
+		values = append(values, formatValue(fieldValue(task, key.Field)))
+	}
+	data, _ := json.Marshal(queryCursor{Sort: q.sortSpec(), Values: values})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (q *Query) decodeCursor(cursor string) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var c queryCursor
+	if err = json.Unmarshal(data, &c); err != nil || c.Sort != q.sortSpec() || len(c.Values) != len(q.Sort) {
+		return nil, errInvalidCursor
+	}
+	after := make([]interface{}, 0, len(c.Values))
+	for i, raw := range c.Values {
+		value, err := parseValue(queryFields[q.Sort[i].Field], raw)
+		if err != nil {
+			return nil, errInvalidCursor
+		}
+		after = append(after, value)
+	}
+	return after, nil
+}
+
+func (q *Query) sortSpec() string {
+	keys := make([]string, 0, len(q.Sort))
+	for _, key := range q.Sort {
+		if key.Desc {
+			keys = append(keys, "-"+key.Field)
+		} else {
+			keys = append(keys, key.Field)
+		}
+	}
+	return strings.Join(keys, ",")
+}
+
+// Apply evaluates the query over tasks in memory. Strings are ordered by
+// bytes, as the SQL query orders them with the "C" collation.
+func (q *Query) Apply(tasks []*model.Task) []*model.Task {
+	matched := make([]*model.Task, 0)
+	for _, task := range tasks {
+		if q.Match(task) && (q.After == nil || q.compareAfter(task) > 0) {
+			matched = append(matched, task)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return q.compare(matched[i], matched[j]) < 0
+	})
+	if len(matched) > q.Limit {
+		matched = matched[:q.Limit]
+	}
+	return matched
+}
+
+// Match reports whether task meets every condition.
+func (q *Query) Match(task *model.Task) bool {
+	for _, c := range q.Conditions {
+		if !c.match(task) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c Condition) match(task *model.Task) bool {
+	value := fieldValue(task, c.Field)
+	if c.Op == OpContains {
+		return strings.Contains(strings.ToLower(value.(string)), strings.ToLower(c.Value.(string)))
+	}
+	cmp := compareValues(value, c.Value)
+	switch c.Op {
+	case OpEq:
+		return cmp == 0
+	case OpNe:
+		return cmp != 0
+	case OpLt:
+		return cmp < 0
+	case OpLte:
+		return cmp <= 0
+	case OpGt:
+		return cmp > 0
+	case OpGte:
+		return cmp >= 0
+	}
+	return false
+}
+
+func (q *Query) compare(a, b *model.Task) int {
+	for _, key := range q.Sort {
+		cmp := compareValues(fieldValue(a, key.Field), fieldValue(b, key.Field))
+		if key.Desc {
+			cmp = -cmp
+		}
+		if cmp != 0 {
+			return cmp
+		}
+	}
+	return 0
+}
+
+// compareAfter compares task with the cursor position.
+func (q *Query) compareAfter(task *model.Task) int {
+	for i, key := range q.Sort {
+		cmp := compareValues(fieldValue(task, key.Field), q.After[i])
+		if key.Desc {
+			cmp = -cmp
+		}
+		if cmp != 0 {
+			return cmp
+		}
+	}
+	return 0
+}
+
+func fieldValue(task *model.Task, field string) interface{} {
+	switch field {
+	case "id":
+		return task.ID
+	case "title":
+		return task.Title
+	case "description":
+		return task.Description
+	case "status":
+		return task.Status
+	case "date":
+		return task.Date
+	case "created_at":
+		return task.CreatedAt
+	case "updated_at":
+		return task.UpdatedAt
+	}
+	panic("unknown query field " + field)
+}
+
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case int64:
+		b := b.(int64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(a, b.(string))
+	case bool:
+		b := b.(bool)
+		switch {
+		case a == b:
+			return 0
+		case b:
+			return -1
+		}
+		return 1
+	case time.Time:
+		b := b.(time.Time)
+		switch {
+		case a.Before(b):
+			return -1
+		case a.After(b):
+			return 1
+		}
+		return 0
+	}
+	panic(fmt.Sprintf("cannot compare %T", a))
+}
@@ -0,0 +1,81 @@
+package task
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// A client that wrote a task is given the time of the write as a token, in
+// lastWriteHeader and in the lastWriteCookie cookie. Its later requests carry
+// the token back, through any instance, and their list queries skip the
+// replica until it has had time to catch up.
+const (
+	lastWriteHeader = "X-Last-Write"
+	lastWriteCookie = "last_write"
+)
+
+type primaryKey struct{}
+
+type lastWriteKey struct{}
+
+// WithPrimary pins the queries made with ctx to the primary database, for
+// callers that must see their own or the latest writes.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func pinnedToPrimary(ctx context.Context) bool {
+	pinned, _ := ctx.Value(primaryKey{}).(bool)
+	return pinned
+}
+
+// WithLastWrite tells the storage when the client behind ctx last wrote, so
+// that its list queries see that write.
+func WithLastWrite(ctx context.Context, written time.Time) context.Context {
+	return context.WithValue(ctx, lastWriteKey{}, written)
+}
+
+// reader picks the pool for a list query. The replica is skipped inside a
+// transaction, when ctx is pinned to the primary, and for a while after the
+// client's last write, so that a client listing tasks right after changing
+// one does not get a lagging answer.
+func (d *TaskStorage) reader(ctx context.Context) querier {
+	if d.replica == nil || pinnedToPrimary(ctx) || txFromContext(ctx) != nil {
+		return d.conn(ctx)
+	}
+	if written, ok := ctx.Value(lastWriteKey{}).(time.Time); ok && time.Since(written) < d.readYourWrites {
+		return d.pool
+	}
+	return d.replica
+}
+
+// setLastWrite hands the client the token of a write it has just made.
+func setLastWrite(w http.ResponseWriter) {
+	token := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	w.Header().Set(lastWriteHeader, token)
+	http.SetCookie(w, &http.Cookie{
+		Name:     lastWriteCookie,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// readContext is the request context carrying the client's last write token,
+// taken from lastWriteHeader or else from the cookie.
+func readContext(r *http.Request) context.Context {
+	token := r.Header.Get(lastWriteHeader)
+	if token == "" {
+		if cookie, err := r.Cookie(lastWriteCookie); err == nil {
+			token = cookie.Value
+		}
+	}
+	millis, err := strconv.ParseInt(token, 10, 64)
+	if err != nil {
+		return r.Context()
+	}
+	return WithLastWrite(r.Context(), time.UnixMilli(millis))
+}
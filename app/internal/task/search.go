@@ -0,0 +1,94 @@
+package task
+
+import (
+	"html"
+	"strings"
+)
+
+const (
+	searchDefaultLimit = 20
+	searchMaxLimit     = 100
+)
+
+// Options of ts_headline. The whole title is returned; the description is cut
+// down to the fragments around the matches. The matches are marked with control
+// characters rather than tags, so that the text can be escaped before the tags
+// are put in by markHighlights.
+const (
+	titleHeadline   = "HighlightAll=true, StartSel=\"\x02\", StopSel=\"\x03\""
+	snippetHeadline = "MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=\" … \", StartSel=\"\x02\", StopSel=\"\x03\""
+)
+
+var headlineMarks = strings.NewReplacer("\x02", "<b>", "\x03", "</b>")
+
+// markHighlights turns a ts_headline result into HTML: the text is escaped and
+// the matches are wrapped with <b> and </b>.
+func markHighlights(headline string) string {
+	return headlineMarks.Replace(html.EscapeString(headline))
+}
+
+// searchTerms splits a search query into lower-case words for MemoryStorage,
+// which has no text search and matches words as substrings.
+func searchTerms(text string) []string {
+	return strings.Fields(strings.ToLower(text))
+}
+
+// rankTerms returns the share of terms found in task, title matches counting
+// twice, and whether every term was found.
+func rankTerms(task *Task, terms []string) (float32, bool) {
+	title, description := strings.ToLower(task.Title), strings.ToLower(task.Description)
+	score := 0
+	for _, term := range terms {
+		inTitle, inDescription := strings.Contains(title, term), strings.Contains(description, term)
+		if !inTitle && !inDescription {
+			return 0, false
+		}
+		if inTitle {
+			score += 2
+		}
+		if inDescription {
+			score++
+		}
+	}
+	return float32(score) / float32(3*len(terms)), true
+}
+
+// highlight escapes text for HTML and wraps every occurrence of the terms with
+// <b> and </b>, as markHighlights does with ts_headline results.
+func highlight(text string, terms []string) string {
+	original := []rune(text)
+	// strings.ToLower maps rune by rune, so the indexes of both slices agree.
+	lower := []rune(strings.ToLower(text))
+
+	var b strings.Builder
+	for i := 0; i < len(original); {
+		n := 0
+		for _, term := range terms {
+			if t := []rune(term); len(t) > n && hasRunePrefix(lower[i:], t) {
+				n = len(t)
+			}
+		}
+		if n == 0 {
+			b.WriteString(html.EscapeString(string(original[i])))
+			i++
+			continue
+		}
+		b.WriteString("<b>")
+		b.WriteString(html.EscapeString(string(original[i : i+n])))
+		b.WriteString("</b>")
+		i += n
+	}
+	return b.String()
+}
+
+func hasRunePrefix(s, prefix []rune) bool {
+	if len(prefix) > len(s) {
+		return false
+	}
+	for i := range prefix {
+		if s[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -5,19 +5,35 @@ import (
 	"Sber/app/pkg/logger"
 	"context"
 	"errors"
-	"time"
+	"net/http"
 )
 
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=Service
 type Service interface {
 	Create(ctx context.Context, task *CreateTask) (*Task, error)
 	GetById(ctx context.Context, id int64) (*Task, error)
-	FindAll() (*[]Task, error)
-	FindAllStatus(ctx context.Context, status bool) (*[]Task, error)
-	FindDateAllAvailable(ctx context.Context, date time.Time, status bool) (*[]Task, error)
+	FindAll(ctx context.Context, page Page) (*[]Task, error)
+	FindAllStatus(ctx context.Context, status bool, page Page) (*[]Task, error)
+	FindDateAllAvailable(ctx context.Context, dates DateRange, status bool, page Page) (*[]Task, error)
+	FindByQuery(ctx context.Context, q *Query) (*[]Task, error)
+	Search(ctx context.Context, text string, limit int) (*[]SearchResult, error)
+	// Update and PartiallyUpdate fail with apperror.ErrVersionMismatch when
+	// task.Version is set and the stored task has another version.
 	Update(ctx context.Context, task *Task) (*Task, error)
 	PartiallyUpdate(ctx context.Context, task *PartiallyUpdateTask) (*Task, error)
-	Delete(id int64) error
+	// Delete moves a task to the trash, from where Restore brings it back.
+	Delete(ctx context.Context, id int64) error
+	Trash(ctx context.Context) (*[]Task, error)
+	Restore(ctx context.Context, id int64) (*Task, error)
+	// History lists the changes of a task, oldest first. Every write above
+	// records one in the same transaction, attributed to the actor in ctx.
+	History(ctx context.Context, id int64) (*[]HistoryEntry, error)
+	// CreateMany, PartiallyUpdateMany and DeleteMany apply a bulk request in
+	// one transaction and report the outcome of every item. Items that fail
+	// on their own do not stop the others; a storage error fails them all.
+	CreateMany(ctx context.Context, input []CreateTask) ([]BulkResult, error)
+	PartiallyUpdateMany(ctx context.Context, input []BulkUpdateTask) ([]BulkResult, error)
+	DeleteMany(ctx context.Context, ids []int64) ([]BulkResult, error)
 }
 
 type service struct {
@@ -42,17 +58,30 @@ func (s *service) Create(ctx context.Context, input *CreateTask) (*Task, error)
 		Status:      input.Status,
 	}
 
-	task, err := s.storage.Create(&t)
+	ctx, tx, err := s.storage.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback(ctx)
+
+	task, err := s.storage.Create(ctx, &t)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.record(ctx, historyEntry(ctx, HistoryCreate, task.ID, diffTasks(nil, task))); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		s.log.Errorf("failed to commit task creation: %v", err)
+		return nil, err
+	}
 	return task, nil
 }
 
 func (s *service) GetById(ctx context.Context, id int64) (*Task, error) {
 	s.log.Info("SERVICE: GET TASK BY ID")
 
-	task, err := s.storage.FindById(id)
+	task, err := s.storage.FindById(ctx, id)
 	if err != nil {
 		if errors.Is(err, apperror.ErrEmptyString) {
 			return nil, err
@@ -63,10 +92,10 @@ func (s *service) GetById(ctx context.Context, id int64) (*Task, error) {
 	return task, nil
 }
 
-func (s *service) FindAll() (*[]Task, error) {
+func (s *service) FindAll(ctx context.Context, page Page) (*[]Task, error) {
 	s.log.Info("SERVICE: GET ALL DOCTORS")
 
-	task, err := s.storage.FindAll()
+	task, err := s.storage.FindAll(ctx, page)
 	if err != nil {
 		if errors.Is(err, apperror.ErrEmptyString) {
 			return nil, err
@@ -77,10 +106,10 @@ func (s *service) FindAll() (*[]Task, error) {
 	return &task, nil
 }
 
-func (s *service) FindAllStatus(ctx context.Context, status bool) (*[]Task, error) {
+func (s *service) FindAllStatus(ctx context.Context, status bool, page Page) (*[]Task, error) {
 	s.log.Info("SERVICE: GET ALL AVAILABLE STATUS TASKS")
 
-	tasks, err := s.storage.FindAllStatus(status)
+	tasks, err := s.storage.FindAllStatus(ctx, status, page)
 	if err != nil {
 		if errors.Is(err, apperror.ErrEmptyString) {
 			return nil, err
@@ -91,10 +120,10 @@ func (s *service) FindAllStatus(ctx context.Context, status bool) (*[]Task, erro
 	return &tasks, nil
 }
 
-func (s *service) FindDateAllAvailable(ctx context.Context, date time.Time, status bool) (*[]Task, error) {
+func (s *service) FindDateAllAvailable(ctx context.Context, dates DateRange, status bool, page Page) (*[]Task, error) {
 	s.log.Info("SERVICE: GET ALL AVAILABLE DATE TASKS")
 
-	tasks, err := s.storage.FindDateAllAvailable(date, status)
+	tasks, err := s.storage.FindDateAllAvailable(ctx, dates, status, page)
 	if err != nil {
 		if errors.Is(err, apperror.ErrEmptyString) {
 			return nil, err
@@ -105,53 +134,397 @@ func (s *service) FindDateAllAvailable(ctx context.Context, date time.Time, stat
 	return &tasks, nil
 }
 
+func (s *service) FindByQuery(ctx context.Context, q *Query) (*[]Task, error) {
+	s.log.Info("SERVICE: FIND TASKS BY QUERY")
+
+	tasks, err := s.storage.FindByQuery(ctx, q)
+	if err != nil {
+		s.log.Warnf("cannot find tasks by query: %v", err)
+		return nil, err
+	}
+	return &tasks, nil
+}
+
+func (s *service) Search(ctx context.Context, text string, limit int) (*[]SearchResult, error) {
+	s.log.Info("SERVICE: SEARCH TASKS")
+
+	results, err := s.storage.Search(ctx, text, limit)
+	if err != nil {
+		s.log.Warnf("cannot search tasks: %v", err)
+		return nil, err
+	}
+	return &results, nil
+}
+
 func (s *service) Update(ctx context.Context, task *Task) (*Task, error) {
 	s.log.Info("SERVICE: UPDATE TASK")
 
-	_, err := s.storage.FindById(task.ID)
+	ctx, tx, err := s.storage.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	current, err := s.storage.FindById(ctx, task.ID)
 	if err != nil {
 		if !errors.Is(err, apperror.ErrEmptyString) {
 			s.log.Errorf("failed to get task: %v", err)
 		}
 		return nil, err
 	}
+	if task.Version != 0 && task.Version != current.Version {
+		return nil, apperror.ErrVersionMismatch
+	}
 
-	task, err = s.storage.Update(task)
+	task, err = s.storage.Update(ctx, task)
 	if err != nil {
 		s.log.Errorf("failed to update task: %v", err)
 		return nil, err
 	}
+	if err = s.record(ctx, historyEntry(ctx, HistoryUpdate, task.ID, diffTasks(current, task))); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		s.log.Errorf("failed to commit task update: %v", err)
+		return nil, err
+	}
 	return task, nil
 }
 
 func (s *service) PartiallyUpdate(ctx context.Context, task *PartiallyUpdateTask) (*Task, error) {
 	s.log.Info("SERVICE: PARTIALLY UPDATE TASK")
 
-	_, err := s.storage.FindById(task.ID)
+	ctx, tx, err := s.storage.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	current, err := s.storage.FindById(ctx, task.ID)
 	if err != nil {
 		if !errors.Is(err, apperror.ErrEmptyString) {
 			s.log.Errorf("failed to get task: %v", err)
 		}
 		return nil, err
 	}
+	if task.Version != 0 && task.Version != current.Version {
+		return nil, apperror.ErrVersionMismatch
+	}
 
-	updatedTask, err := s.storage.PartiallyUpdate(task)
+	updatedTask, err := s.storage.PartiallyUpdate(ctx, task)
 	if err != nil {
 		s.log.Errorf("failed to partially update task: %v", err)
 		return nil, err
 	}
+	if err = s.record(ctx, historyEntry(ctx, HistoryUpdate, updatedTask.ID, diffTasks(current, updatedTask))); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		s.log.Errorf("failed to commit task update: %v", err)
+		return nil, err
+	}
 	return updatedTask, nil
 }
 
-func (s *service) Delete(id int64) error {
+func (s *service) Delete(ctx context.Context, id int64) error {
 	s.log.Info("SERVICE: DELETE TASK")
 
-	err := s.storage.Delete(id)
+	ctx, tx, err := s.storage.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = s.storage.Delete(ctx, id)
 	if err != nil {
 		if !errors.Is(err, apperror.ErrEmptyString) {
 			s.log.Warn("failed to delete task:", err)
 		}
 		return err
 	}
+	if err = s.record(ctx, historyEntry(ctx, HistoryDelete, id, make([]FieldChange, 0))); err != nil {
+		return err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		s.log.Errorf("failed to commit task deletion: %v", err)
+		return err
+	}
 	return nil
 }
+
+func (s *service) Trash(ctx context.Context) (*[]Task, error) {
+	s.log.Info("SERVICE: GET DELETED TASKS")
+
+	tasks, err := s.storage.FindDeleted(ctx)
+	if err != nil {
+		s.log.Warnf("cannot find deleted tasks: %v", err)
+		return nil, err
+	}
+	return &tasks, nil
+}
+
+func (s *service) Restore(ctx context.Context, id int64) (*Task, error) {
+	s.log.Info("SERVICE: RESTORE TASK")
+
+	ctx, tx, err := s.storage.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	task, err := s.storage.Restore(ctx, id)
+	if err != nil {
+		if !errors.Is(err, apperror.ErrEmptyString) {
+			s.log.Warn("failed to restore task:", err)
+		}
+		return nil, err
+	}
+	if err = s.record(ctx, historyEntry(ctx, HistoryRestore, id, make([]FieldChange, 0))); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		s.log.Errorf("failed to commit task restore: %v", err)
+		return nil, err
+	}
+	return task, nil
+}
+
+func (s *service) History(ctx context.Context, id int64) (*[]HistoryEntry, error) {
+	s.log.Info("SERVICE: GET TASK HISTORY")
+
+	entries, err := s.storage.FindHistory(ctx, id)
+	if err != nil {
+		s.log.Warnf("cannot find task history: %v", err)
+		return nil, err
+	}
+	// Tasks created before history was recorded have none; only a task that
+	// does not exist is not found.
+	if len(entries) == 0 {
+		if _, err = s.storage.FindById(ctx, id); err != nil {
+			if !errors.Is(err, apperror.ErrEmptyString) {
+				s.log.Warnf("cannot find task: %v", err)
+			}
+			return nil, err
+		}
+	}
+	return &entries, nil
+}
+
+// record adds history entries for changes made in the transaction of ctx.
+// An update that left every field as it was is not recorded.
+func (s *service) record(ctx context.Context, entries ...*HistoryEntry) error {
+	changed := make([]*HistoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Op != HistoryUpdate || len(entry.Changes) > 0 {
+			changed = append(changed, entry)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	if err := s.storage.AddHistory(ctx, changed...); err != nil {
+		s.log.Errorf("failed to record task history: %v", err)
+		return err
+	}
+	return nil
+}
+
+func historyEntry(ctx context.Context, op string, taskID int64, changes []FieldChange) *HistoryEntry {
+	return &HistoryEntry{
+		TaskID:  taskID,
+		Op:      op,
+		Changes: changes,
+		Actor:   actorFrom(ctx),
+	}
+}
+
+var (
+	errBulkMissingID = errors.New("task id is required")
+	errBulkDuplicate = errors.New("task appears more than once in the request")
+	errBulkNotFound  = errors.New("task is not found")
+)
+
+func (s *service) CreateMany(ctx context.Context, input []CreateTask) ([]BulkResult, error) {
+	s.log.Info("SERVICE: CREATE TASKS")
+
+	tasks := make([]*Task, 0, len(input))
+	for _, item := range input {
+		tasks = append(tasks, &Task{
+			Title:       item.Title,
+			Description: item.Description,
+			Date:        item.Date,
+			Status:      item.Status,
+		})
+	}
+
+	ctx, tx, err := s.storage.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	created, err := s.storage.CreateMany(ctx, tasks)
+	if err != nil {
+		s.log.Errorf("failed to create tasks: %v", err)
+		return nil, err
+	}
+	results := make([]BulkResult, 0, len(created))
+	entries := make([]*HistoryEntry, 0, len(created))
+	for _, task := range created {
+		results = append(results, BulkResult{ID: task.ID, Status: http.StatusCreated, Task: task})
+		entries = append(entries, historyEntry(ctx, HistoryCreate, task.ID, diffTasks(nil, task)))
+	}
+	if err = s.record(ctx, entries...); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		s.log.Errorf("failed to commit task creation: %v", err)
+		return nil, err
+	}
+	return results, nil
+}
+
+func (s *service) PartiallyUpdateMany(ctx context.Context, input []BulkUpdateTask) ([]BulkResult, error) {
+	s.log.Info("SERVICE: PARTIALLY UPDATE TASKS")
+
+	ids := make([]int64, 0, len(input))
+	for _, item := range input {
+		ids = append(ids, item.ID)
+	}
+	results := bulkResults(ids)
+
+	ctx, tx, err := s.storage.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	found, err := s.storage.FindByIds(ctx, ids)
+	if err != nil {
+		s.log.Errorf("failed to get tasks: %v", err)
+		return nil, err
+	}
+	current := make(map[int64]*Task, len(found))
+	for i := range found {
+		current[found[i].ID] = &found[i]
+	}
+
+	tasks := make([]*PartiallyUpdateTask, 0, len(input))
+	positions := make([]int, 0, len(input))
+	for i, item := range input {
+		if results[i].Status != 0 {
+			continue
+		}
+		task, ok := current[item.ID]
+		switch {
+		case !ok:
+			results[i].fail(http.StatusNotFound, errBulkNotFound)
+		case item.Version != 0 && item.Version != task.Version:
+			results[i].fail(http.StatusPreconditionFailed, apperror.ErrVersionMismatch)
+		default:
+			patch := item.PartiallyUpdateTask
+			tasks = append(tasks, &patch)
+			positions = append(positions, i)
+		}
+	}
+	if len(tasks) == 0 {
+		return results, nil
+	}
+
+	updated, err := s.storage.PartiallyUpdateMany(ctx, tasks)
+	if err != nil {
+		s.log.Errorf("failed to partially update tasks: %v", err)
+		return nil, err
+	}
+	entries := make([]*HistoryEntry, 0, len(updated))
+	for j, task := range updated {
+		i := positions[j]
+		if task == nil {
+			results[i].fail(http.StatusNotFound, errBulkNotFound)
+			continue
+		}
+		results[i].Status, results[i].Task = http.StatusOK, task
+		entries = append(entries, historyEntry(ctx, HistoryUpdate, task.ID, diffTasks(current[task.ID], task)))
+	}
+	if err = s.record(ctx, entries...); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		s.log.Errorf("failed to commit task update: %v", err)
+		return nil, err
+	}
+	return results, nil
+}
+
+func (s *service) DeleteMany(ctx context.Context, ids []int64) ([]BulkResult, error) {
+	s.log.Info("SERVICE: DELETE TASKS")
+
+	results := bulkResults(ids)
+	valid := make([]int64, 0, len(ids))
+	for i, id := range ids {
+		if results[i].Status == 0 {
+			valid = append(valid, id)
+		}
+	}
+	if len(valid) == 0 {
+		return results, nil
+	}
+
+	ctx, tx, err := s.storage.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	deleted, err := s.storage.DeleteMany(ctx, valid)
+	if err != nil {
+		s.log.Warn("failed to delete tasks:", err)
+		return nil, err
+	}
+	entries := make([]*HistoryEntry, 0, len(deleted))
+	isDeleted := make(map[int64]bool, len(deleted))
+	for _, id := range deleted {
+		isDeleted[id] = true
+		entries = append(entries, historyEntry(ctx, HistoryDelete, id, make([]FieldChange, 0)))
+	}
+	for i := range results {
+		switch {
+		case results[i].Status != 0:
+		case isDeleted[results[i].ID]:
+			results[i].Status = http.StatusOK
+		default:
+			results[i].fail(http.StatusNotFound, errBulkNotFound)
+		}
+	}
+	if err = s.record(ctx, entries...); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		s.log.Errorf("failed to commit task deletion: %v", err)
+		return nil, err
+	}
+	return results, nil
+}
+
+// bulkResults prepares one result per ID and fails the items that cannot be
+// processed at all: a missing ID or one given more than once. The Status of
+// the other items is left zero.
+func bulkResults(ids []int64) []BulkResult {
+	results := make([]BulkResult, len(ids))
+	seen := make(map[int64]bool, len(ids))
+	for i, id := range ids {
+		results[i].ID = id
+		switch {
+		case id <= 0:
+			results[i].fail(http.StatusBadRequest, errBulkMissingID)
+		case seen[id]:
+			results[i].fail(http.StatusBadRequest, errBulkDuplicate)
+		}
+		seen[id] = true
+	}
+	return results
+}
+
+func (r *BulkResult) fail(status int, err error) {
+	r.Status, r.Error = status, err.Error()
+}
@@ -1,14 +1,48 @@
 package task
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 type Storage interface {
-	Create(task *Task) (*Task, error)
-	FindById(id int64) (*Task, error)
-	FindAll() ([]Task, error)
-	FindAllStatus(status bool) ([]Task, error)
-	FindDateAllAvailable(date time.Time, status bool) ([]Task, error)
-	Update(task *Task) (*Task, error)
-	PartiallyUpdate(task *PartiallyUpdateTask) (*Task, error)
-	Delete(id int64) error
+	// Begin opens a transaction. Calls made with the returned context are
+	// part of it until it is committed or rolled back.
+	Begin(ctx context.Context) (context.Context, Tx, error)
+	Create(ctx context.Context, task *Task) (*Task, error)
+	FindById(ctx context.Context, id int64) (*Task, error)
+	// FindAll, FindAllStatus and FindDateAllAvailable list tasks ordered by
+	// ID, limited to the given page. FindDateAllAvailable selects the tasks
+	// dated within the range.
+	FindAll(ctx context.Context, page Page) ([]Task, error)
+	FindAllStatus(ctx context.Context, status bool, page Page) ([]Task, error)
+	FindDateAllAvailable(ctx context.Context, dates DateRange, status bool, page Page) ([]Task, error)
+	FindByQuery(ctx context.Context, q *Query) ([]Task, error)
+	// Search finds the tasks matching a full-text query, best match first.
+	Search(ctx context.Context, text string, limit int) ([]SearchResult, error)
+	Update(ctx context.Context, task *Task) (*Task, error)
+	PartiallyUpdate(ctx context.Context, task *PartiallyUpdateTask) (*Task, error)
+	// Delete moves a task to the trash.
+	Delete(ctx context.Context, id int64) error
+	// FindByIds returns the tasks with the given IDs that are not in the
+	// trash, ordered by ID. Inside a transaction their rows are locked.
+	FindByIds(ctx context.Context, ids []int64) ([]Task, error)
+	// CreateMany, PartiallyUpdateMany and DeleteMany change many tasks with
+	// one round trip. PartiallyUpdateMany returns the updated tasks in input
+	// order, with nil for a task that was not found; DeleteMany returns the
+	// IDs it moved to the trash.
+	CreateMany(ctx context.Context, tasks []*Task) ([]*Task, error)
+	PartiallyUpdateMany(ctx context.Context, tasks []*PartiallyUpdateTask) ([]*Task, error)
+	DeleteMany(ctx context.Context, ids []int64) ([]int64, error)
+	// FindDeleted lists the tasks in the trash, most recently deleted first.
+	FindDeleted(ctx context.Context) ([]Task, error)
+	// Restore takes a task out of the trash.
+	Restore(ctx context.Context, id int64) (*Task, error)
+	// Purge removes for good the tasks deleted before the given time and
+	// returns how many there were. Their history is kept.
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	// AddHistory records changes of tasks; ID and ChangedAt are filled in.
+	AddHistory(ctx context.Context, entries ...*HistoryEntry) error
+	// FindHistory lists the changes of a task, oldest first.
+	FindHistory(ctx context.Context, taskID int64) ([]HistoryEntry, error)
 }
@@ -7,14 +7,22 @@ import "time"
 // "id": 1, "title": "Задача 1",
 // "description": "Описание задачи 1",
 // "date": "2023-09-21T12:00:00Z",
-// "status": false
+// "status": false,
+// "created_at": "2023-09-19T17:00:00Z",
+// "updated_at": "2023-09-20T08:30:00Z",
+// "version": 1
 // }
 type Task struct {
 	ID          int64     `json:"id" example:"1"`
 	Title       string    `json:"title" example:"Задача 1"`
-	Description string    `json:"description"example:"Описание задачи 1"`
-	Date        time.Time `json:"date"example:"2023-09-21T12:00:00Z"`
-	Status      bool      `json:"status"example:"false"`
+	Description string    `json:"description" example:"Описание задачи 1"`
+	Date        time.Time `json:"date" example:"2023-09-21T12:00:00Z"`
+	Status      bool      `json:"status" example:"false"`
+	CreatedAt   time.Time `json:"created_at" example:"2023-09-19T17:00:00Z"`
+	UpdatedAt   time.Time `json:"updated_at" example:"2023-09-20T08:30:00Z"`
+	Version     int64     `json:"version" example:"1"`
+	// DeletedAt is set while the task is in the trash.
+	DeletedAt *time.Time `json:"deleted_at,omitempty" example:"2023-09-25T18:00:00Z"`
 }
 
 // @Example CreateTask
@@ -45,4 +53,36 @@ type PartiallyUpdateTask struct {
 	Description *string    `json:"description" example:"Обновленное Описание задачи 1"`
 	Date        *time.Time `json:"date" example:"Обновленная дата 2023-09-21T12:00:00Z"`
 	Status      *bool      `json:"status" example:"false"`
+	// Version is the expected version from If-Match, 0 when not given.
+	Version int64 `json:"-"`
+}
+
+// @Example BulkUpdateTask
+// {
+// "id": 1,
+// "status": true,
+// "version": 3
+// }
+type BulkUpdateTask struct {
+	PartiallyUpdateTask
+	// Version plays the part of If-Match for this task, 0 skips the check.
+	Version int64 `json:"version,omitempty" example:"3"`
+}
+
+// BulkResult is the outcome for one item of a bulk request, in request order.
+// Status is the HTTP status the item would get on its own.
+type BulkResult struct {
+	ID     int64  `json:"id,omitempty" example:"1"`
+	Status int    `json:"status" example:"200"`
+	Task   *Task  `json:"task,omitempty"`
+	Error  string `json:"error,omitempty" example:""`
+}
+
+// SearchResult is a task found by full-text search. The highlights are HTML:
+// the text is escaped and the matched words are wrapped with <b> and </b>.
+type SearchResult struct {
+	Task
+	Rank           float32 `json:"rank" example:"0.6079271"`
+	TitleHighlight string  `json:"title_highlight" example:"<b>Задача</b> 1 &amp; 2"`
+	Snippet        string  `json:"snippet" example:"Описание <b>задачи</b> 1"`
 }
@@ -0,0 +1,74 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"sync"
+)
+
+// Tx is a unit of work opened with Storage.Begin. Storage methods called with
+// the context returned by Begin take part in it. Rollback after Commit is a
+// no-op, so it can always be deferred.
+type Tx interface {
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// querier is implemented by both the pool and a pgx transaction.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+type txKey struct{}
+
+// pgTx delays cache updates until the transaction commits, so that a rolled
+// back write never reaches the cache.
+type pgTx struct {
+	tx          pgx.Tx
+	afterCommit []func()
+}
+
+func (t *pgTx) Commit(ctx context.Context) error {
+	if err := t.tx.Commit(ctx); err != nil {
+		return err
+	}
+	for _, fn := range t.afterCommit {
+		fn()
+	}
+	t.afterCommit = nil
+	return nil
+}
+
+func (t *pgTx) Rollback(ctx context.Context) error {
+	if err := t.tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+		return err
+	}
+	return nil
+}
+
+func txFromContext(ctx context.Context) *pgTx {
+	tx, _ := ctx.Value(txKey{}).(*pgTx)
+	return tx
+}
+
+// memoryTx serializes units of work on MemoryStorage. Writes are applied
+// immediately and are not undone by Rollback.
+type memoryTx struct {
+	once   sync.Once
+	unlock func()
+}
+
+func (t *memoryTx) Commit(context.Context) error {
+	t.once.Do(t.unlock)
+	return nil
+}
+
+func (t *memoryTx) Rollback(context.Context) error {
+	t.once.Do(t.unlock)
+	return nil
+}
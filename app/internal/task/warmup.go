@@ -0,0 +1,191 @@
+package task
+
+import (
+	"Sber/app/internal/cache"
+	"Sber/app/pkg/logger"
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+const (
+	WarmupLoading = "loading"
+	WarmupReady   = "ready"
+)
+
+// defaultWarmupPageSize is used when the configured page size is not positive.
+const defaultWarmupPageSize = 1000
+
+// WarmupStatus is the warm-up progress reported by the readiness endpoint.
+// Total is counted when loading starts and is only an estimate.
+type WarmupStatus struct {
+	State  string `json:"state"`
+	Loaded int    `json:"loaded"`
+	Total  int64  `json:"total"`
+	Error  string `json:"error,omitempty"`
+}
+
+/// Структура Warmup загружающая задачи в кэш в фоне \\\
+
+type Warmup struct {
+	log          logger.Logger
+	pool         *pgxpool.Pool
+	cache        cache.Cache
+	pageSize     int
+	snapshotPath string
+	complete     bool
+
+	mu     sync.Mutex
+	status WarmupStatus
+}
+
+// NewWarmup prepares loading every task into cache, in pages of pageSize,
+// from the snapshot at snapshotPath when there is one. Once loaded, the cache
+// is marked complete if complete is set.
+func NewWarmup(pool *pgxpool.Pool, cache cache.Cache, pageSize int, snapshotPath string, complete bool) *Warmup {
+	if pageSize <= 0 {
+		pageSize = defaultWarmupPageSize
+	}
+	return &Warmup{
+		log:          logger.GetLogger(),
+		pool:         pool,
+		cache:        cache,
+		pageSize:     pageSize,
+		snapshotPath: snapshotPath,
+		complete:     complete,
+		status:       WarmupStatus{State: WarmupLoading},
+	}
+}
+
+// Run loads the cache, retrying with exponential backoff until it succeeds
+// or ctx is cancelled.
+func (w *Warmup) Run(ctx context.Context) {
+	backoff := listenMinBackoff
+	for {
+		err := w.load(ctx)
+		if err == nil {
+			w.setReady()
+			w.log.Infof("WARMUP: cache holds %d tasks and is ready", w.cache.Len())
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		w.mu.Lock()
+		w.status.Error = err.Error()
+		w.mu.Unlock()
+		w.log.Errorf("WARMUP: %v, retrying in %v", err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > listenMaxBackoff {
+			backoff = listenMaxBackoff
+		}
+	}
+}
+
+// Skip marks the warm-up as done without loading anything, for caches that
+// are filled on demand.
+func (w *Warmup) Skip() {
+	w.setReady()
+}
+
+func (w *Warmup) Status() WarmupStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.status
+}
+
+func (w *Warmup) Ready() bool {
+	return w.Status().State == WarmupReady
+}
+
+func (w *Warmup) setReady() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.status.State = WarmupReady
+	w.status.Error = ""
+}
+
+func (w *Warmup) load(ctx context.Context) error {
+	w.cache.SetComplete(false)
+
+	var total int64
+	if err := w.pool.QueryRow(ctx, `SELECT count(*) FROM Task`).Scan(&total); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	w.status.Total = total
+	w.status.Loaded = 0
+	w.mu.Unlock()
+
+	restored, err := w.restore(ctx)
+	if err != nil {
+		return err
+	}
+	if !restored {
+		w.log.Info("WARMUP: loading tasks from the database")
+		if err = w.loadPages(ctx, 0); err != nil {
+			return err
+		}
+		if err = dropDeleted(ctx, w.pool, w.cache); err != nil {
+			return err
+		}
+	}
+
+	// Tasks inserted by other instances are only cached by the listener once
+	// the cache is complete, so pick up any inserted while loading.
+	var lastID int64
+	if tasks := w.cache.List(); len(tasks) > 0 {
+		lastID = tasks[len(tasks)-1].ID
+	}
+	w.cache.SetComplete(w.complete)
+	return w.loadPages(ctx, lastID)
+}
+
+// restore loads the snapshot and reconciles it with the database. It reports
+// false when there is no usable snapshot.
+func (w *Warmup) restore(ctx context.Context) (bool, error) {
+	if w.snapshotPath == "" {
+		return false, nil
+	}
+	watermark, err := cache.LoadSnapshot(w.snapshotPath, w.cache)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			w.log.Errorf("WARMUP: cannot use cache snapshot: %v", err)
+		}
+		return false, nil
+	}
+	w.log.Infof("WARMUP: restored %d tasks from snapshot, reconciling", w.cache.Len())
+	if err = reconcileCache(ctx, w.pool, w.cache, watermark); err != nil {
+		return true, err
+	}
+	w.mu.Lock()
+	w.status.Loaded = w.cache.Len()
+	w.mu.Unlock()
+	return true, nil
+}
+
+func (w *Warmup) loadPages(ctx context.Context, afterID int64) error {
+	for {
+		n, lastID, err := cacheTaskPage(ctx, w.pool, w.cache, afterID, w.pageSize)
+		if err != nil {
+			return err
+		}
+		w.mu.Lock()
+		w.status.Loaded += n
+		w.mu.Unlock()
+		if n < w.pageSize {
+			return nil
+		}
+		afterID = lastID
+	}
+}
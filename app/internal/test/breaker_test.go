@@ -0,0 +1,176 @@
+package test
+
+import (
+	"Sber/app/internal/apperror"
+	"Sber/app/internal/cache"
+	"Sber/app/internal/model"
+	"Sber/app/internal/task"
+	"Sber/app/pkg/breaker"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	b := breaker.New(2, 20*time.Millisecond)
+
+	assert.NoError(t, b.Allow())
+	b.Failure()
+	assert.Equal(t, breaker.Closed, b.State())
+	assert.NoError(t, b.Allow())
+	b.Failure()
+	assert.Equal(t, breaker.Open, b.State())
+	assert.ErrorIs(t, b.Allow(), breaker.ErrOpen)
+	assert.Greater(t, b.RetryAfter(), time.Duration(0))
+
+	time.Sleep(30 * time.Millisecond)
+	assert.NoError(t, b.Allow(), "a probe is let through after the cooldown")
+	assert.Equal(t, breaker.HalfOpen, b.State())
+	assert.ErrorIs(t, b.Allow(), breaker.ErrOpen, "only one probe at a time")
+	b.Failure()
+	assert.Equal(t, breaker.Open, b.State())
+
+	time.Sleep(30 * time.Millisecond)
+	assert.NoError(t, b.Allow())
+	b.Success()
+	assert.Equal(t, breaker.Closed, b.State())
+	assert.NoError(t, b.Allow())
+}
+
+var errDatabaseDown = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+// failingStorage fails every call the way an unreachable database does.
+type failingStorage struct{}
+
+func (failingStorage) Begin(ctx context.Context) (context.Context, task.Tx, error) {
+	return ctx, nil, errDatabaseDown
+}
+func (failingStorage) Create(context.Context, *task.Task) (*task.Task, error) {
+	return nil, errDatabaseDown
+}
+func (failingStorage) FindById(context.Context, int64) (*task.Task, error) {
+	return nil, errDatabaseDown
+}
+func (failingStorage) FindAll(context.Context, task.Page) ([]task.Task, error) {
+	return nil, errDatabaseDown
+}
+func (failingStorage) FindAllStatus(context.Context, bool, task.Page) ([]task.Task, error) {
+	return nil, errDatabaseDown
+}
+func (failingStorage) FindDateAllAvailable(context.Context, task.DateRange, bool, task.Page) ([]task.Task, error) {
+	return nil, errDatabaseDown
+}
+func (failingStorage) FindByQuery(context.Context, *task.Query) ([]task.Task, error) {
+	return nil, errDatabaseDown
+}
+func (failingStorage) Search(context.Context, string, int) ([]task.SearchResult, error) {
+	return nil, errDatabaseDown
+}
+func (failingStorage) Update(context.Context, *task.Task) (*task.Task, error) {
+	return nil, errDatabaseDown
+}
+func (failingStorage) PartiallyUpdate(context.Context, *task.PartiallyUpdateTask) (*task.Task, error) {
+	return nil, errDatabaseDown
+}
+func (failingStorage) Delete(context.Context, int64) error {
+	return errDatabaseDown
+}
+func (failingStorage) FindByIds(context.Context, []int64) ([]task.Task, error) {
+	return nil, errDatabaseDown
+}
+func (failingStorage) CreateMany(context.Context, []*task.Task) ([]*task.Task, error) {
+	return nil, errDatabaseDown
+}
+func (failingStorage) PartiallyUpdateMany(context.Context, []*task.PartiallyUpdateTask) ([]*task.Task, error) {
+	return nil, errDatabaseDown
+}
+func (failingStorage) DeleteMany(context.Context, []int64) ([]int64, error) {
+	return nil, errDatabaseDown
+}
+func (failingStorage) FindDeleted(context.Context) ([]task.Task, error) {
+	return nil, errDatabaseDown
+}
+func (failingStorage) Restore(context.Context, int64) (*task.Task, error) {
+	return nil, errDatabaseDown
+}
+func (failingStorage) Purge(context.Context, time.Time) (int64, error) {
+	return 0, errDatabaseDown
+}
+func (failingStorage) AddHistory(context.Context, ...*task.HistoryEntry) error {
+	return errDatabaseDown
+}
+func (failingStorage) FindHistory(context.Context, int64) ([]task.HistoryEntry, error) {
+	return nil, errDatabaseDown
+}
+
+func TestBreakerStorageServesFromCache(t *testing.T) {
+	c := cache.NewCache()
+	c.Set(&model.Task{ID: 1, Title: "Задача 1", Status: true})
+	c.Set(&model.Task{ID: 2, Title: "Задача 2"})
+	c.SetComplete(true)
+	storage := task.NewBreakerStorage(failingStorage{}, breaker.New(2, time.Minute), c)
+	ctx := context.Background()
+
+	_, err := storage.FindAll(ctx, task.Page{})
+	assert.ErrorIs(t, err, errDatabaseDown, "a single failure does not trip the breaker")
+
+	tasks, err := storage.FindAll(ctx, task.Page{})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 2)
+
+	tasks, err = storage.FindAllStatus(ctx, true, task.Page{})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 1)
+
+	found, err := storage.FindById(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "Задача 2", found.Title)
+	_, err = storage.FindById(ctx, 3)
+	assert.ErrorIs(t, err, apperror.ErrUnavailable)
+
+	_, err = storage.Create(ctx, &task.Task{Title: "Новая задача"})
+	assert.ErrorIs(t, err, apperror.ErrUnavailable)
+	assert.ErrorIs(t, storage.Delete(ctx, 1), apperror.ErrUnavailable)
+
+	c.SetComplete(false)
+	_, err = storage.FindAll(ctx, task.Page{})
+	assert.ErrorIs(t, err, apperror.ErrUnavailable, "a partial cache cannot answer list queries")
+}
+
+// rejectingStorage is reachable but rejects every task it is given.
+type rejectingStorage struct {
+	failingStorage
+}
+
+func (rejectingStorage) Create(context.Context, *task.Task) (*task.Task, error) {
+	return nil, fmt.Errorf("failed to execute create task query: %w",
+		&pgconn.PgError{Code: "22021", Message: "invalid byte sequence for encoding \"UTF8\": 0x00"})
+}
+func (rejectingStorage) Delete(context.Context, int64) error {
+	return &pgconn.PgError{Code: "23503", Message: "violates foreign key constraint"}
+}
+
+func TestBreakerStorageIgnoresRejectedQueries(t *testing.T) {
+	b := breaker.New(2, time.Minute)
+	storage := task.NewBreakerStorage(rejectingStorage{}, b, cache.NewCache())
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		_, err := storage.Create(ctx, &task.Task{Title: "\x00"})
+		var pgErr *pgconn.PgError
+		assert.ErrorAs(t, err, &pgErr, "the error is returned as it is")
+		assert.Error(t, storage.Delete(ctx, 1))
+	}
+	assert.Equal(t, breaker.Closed, b.State(), "bad input says nothing about the database")
+
+	_, err := storage.FindAll(ctx, task.Page{})
+	assert.ErrorIs(t, err, errDatabaseDown)
+	_, err = storage.FindAll(ctx, task.Page{})
+	assert.ErrorIs(t, err, apperror.ErrUnavailable)
+	assert.Equal(t, breaker.Open, b.State())
+}
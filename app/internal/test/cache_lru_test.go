@@ -0,0 +1,63 @@
+package test
+
+import (
+	"Sber/app/internal/cache"
+	"Sber/app/internal/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLRUCacheEviction(t *testing.T) {
+	c := cache.NewLRU(2, 0)
+	assert.False(t, c.Complete())
+
+	c.Set(&model.Task{ID: 1})
+	c.Set(&model.Task{ID: 2})
+	_, ok := c.Get(1)
+	assert.True(t, ok)
+
+	c.Set(&model.Task{ID: 3})
+
+	_, ok = c.Get(2)
+	assert.False(t, ok, "least recently used task must be evicted")
+	_, ok = c.Get(1)
+	assert.True(t, ok)
+	_, ok = c.Get(3)
+	assert.True(t, ok)
+	assert.Equal(t, 2, c.Len())
+
+	c.Set(&model.Task{ID: 1, Title: "Обновлено"})
+	task, _ := c.Get(1)
+	assert.Equal(t, "Обновлено", task.Title)
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestLRUCacheTTL(t *testing.T) {
+	c := cache.NewLRU(0, 20*time.Millisecond)
+
+	c.Set(&model.Task{ID: 1})
+	_, ok := c.Get(1)
+	assert.True(t, ok)
+
+	time.Sleep(40 * time.Millisecond)
+
+	_, ok = c.Get(1)
+	assert.False(t, ok)
+	assert.Empty(t, c.List())
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestNewCacheSelectsImplementation(t *testing.T) {
+	for _, c := range []cache.Cache{cache.New(0, 0), cache.New(100, 0), cache.New(0, time.Minute)} {
+		assert.False(t, c.Complete(), "a new cache holds no tasks yet")
+	}
+
+	unbounded := cache.New(0, 0)
+	unbounded.SetComplete(true)
+	assert.True(t, unbounded.Complete())
+
+	bounded := cache.New(100, 0)
+	bounded.SetComplete(true)
+	assert.False(t, bounded.Complete(), "a bounded cache is never complete")
+}
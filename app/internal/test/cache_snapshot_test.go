@@ -0,0 +1,42 @@
+package test
+
+import (
+	"Sber/app/internal/cache"
+	"Sber/app/internal/model"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCacheSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot.json")
+	updated := time.Date(2023, 9, 20, 8, 30, 0, 0, time.UTC)
+
+	source := cache.NewCache()
+	source.Set(&model.Task{ID: 1, Title: "Задача 1", Date: updated.AddDate(0, 0, 1), UpdatedAt: updated})
+	source.Set(&model.Task{ID: 2, Title: "Задача 2", Status: true, UpdatedAt: updated.Add(time.Hour)})
+	assert.NoError(t, cache.SaveSnapshot(path, source))
+
+	restored := cache.NewCache()
+	watermark, err := cache.LoadSnapshot(path, restored)
+	assert.NoError(t, err)
+	assert.True(t, updated.Add(time.Hour).Equal(watermark))
+	assert.Equal(t, []int64{1, 2}, taskIDs(restored.List()))
+	task, _ := restored.Get(1)
+	assert.Equal(t, "Задача 1", task.Title)
+	assert.Equal(t, []int64{2}, taskIDs(restored.ByStatus(true, 0, 0)))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(path, []byte(strings.Replace(string(data), "Задача 2", "Задача 3", 1)), 0o644))
+	_, err = cache.LoadSnapshot(path, cache.NewCache())
+	assert.Error(t, err, "a corrupted snapshot must be rejected")
+
+	_, err = cache.LoadSnapshot(filepath.Join(t.TempDir(), "missing.json"), cache.NewCache())
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+}
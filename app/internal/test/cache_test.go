@@ -0,0 +1,116 @@
+package test
+
+import (
+	"Sber/app/internal/cache"
+	"Sber/app/internal/model"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	c := cache.NewCache()
+	for _, id := range []int64{3, 1, 2} {
+		c.Set(&model.Task{ID: id, Title: "Задача", Status: id != 2})
+	}
+
+	task, ok := c.Get(1)
+	assert.True(t, ok)
+	task.Title = "Изменено снаружи"
+	task, _ = c.Get(1)
+	assert.Equal(t, "Задача", task.Title, "cache must not expose stored tasks")
+
+	tasks := c.List()
+	assert.Len(t, tasks, 3)
+	for i, tk := range tasks {
+		assert.Equal(t, int64(i+1), tk.ID)
+	}
+
+	tasks = c.Filter(func(task *model.Task) bool {
+		return task.Status
+	})
+	assert.Len(t, tasks, 2)
+	assert.Equal(t, int64(1), tasks[0].ID)
+	assert.Equal(t, int64(3), tasks[1].ID)
+
+	c.Delete(3)
+	_, ok = c.Get(3)
+	assert.False(t, ok)
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestCacheConcurrentAccess(t *testing.T) {
+	c := cache.NewCache()
+
+	var wg sync.WaitGroup
+	for i := int64(1); i <= 20; i++ {
+		wg.Add(2)
+		go func(id int64) {
+			defer wg.Done()
+			c.Set(&model.Task{ID: id})
+			c.Get(id)
+			c.Delete(id)
+			c.Set(&model.Task{ID: id, Status: true})
+		}(i)
+		go func() {
+			defer wg.Done()
+			c.List()
+			c.Filter(func(task *model.Task) bool {
+				return task.Status
+			})
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 20, c.Len())
+}
+
+func TestCacheIndexes(t *testing.T) {
+	day := time.Date(2023, 9, 21, 0, 0, 0, 0, time.UTC)
+	for _, c := range []cache.Cache{cache.NewCache(), cache.NewLRU(100, time.Minute)} {
+		c.Set(&model.Task{ID: 5, Date: day.Add(23 * time.Hour), Status: true})
+		c.Set(&model.Task{ID: 2, Date: day.Add(10 * time.Hour), Status: false})
+		c.Set(&model.Task{ID: 9, Date: day.Add(24 * time.Hour), Status: false})
+		c.Set(&model.Task{ID: 1, Date: day, Status: true})
+
+		assert.Equal(t, []int64{1, 2, 5, 9}, taskIDs(c.List()))
+		assert.Equal(t, []int64{1, 5}, taskIDs(c.ByStatus(true, 0, 0)))
+		assert.Equal(t, []int64{2, 9}, taskIDs(c.ByStatus(false, 0, 0)))
+		assert.Equal(t, []int64{1, 5}, taskIDs(c.ByDateRange(day, day.AddDate(0, 0, 1), true, 0, 0)))
+		assert.Equal(t, []int64{2}, taskIDs(c.ByDateRange(day, day.AddDate(0, 0, 1), false, 0, 0)))
+		msk := time.FixedZone("MSK", 3*60*60)
+		assert.Equal(t, []int64{9}, taskIDs(c.ByDateRange(time.Date(2023, 9, 22, 0, 0, 0, 0, msk), time.Date(2023, 9, 23, 0, 0, 0, 0, msk), false, 0, 0)))
+
+		c.Set(&model.Task{ID: 5, Date: day.AddDate(0, 0, 1), Status: false})
+		assert.Equal(t, []int64{1}, taskIDs(c.ByStatus(true, 0, 0)))
+		assert.Equal(t, []int64{2, 5, 9}, taskIDs(c.ByStatus(false, 0, 0)))
+		assert.Equal(t, []int64{5, 9}, taskIDs(c.ByDateRange(day.AddDate(0, 0, 1), day.AddDate(0, 0, 2), false, 0, 0)))
+
+		c.Delete(2)
+		assert.Equal(t, []int64{5, 9}, taskIDs(c.ByStatus(false, 0, 0)))
+		assert.Equal(t, []int64{1}, taskIDs(c.ByDateRange(day, day.AddDate(0, 0, 1), true, 0, 0)))
+
+		assert.Empty(t, c.ByDateRange(day, day.AddDate(0, 0, 1), false, 0, 0))
+		assert.Equal(t, []int64{5, 9}, taskIDs(c.ByDateRange(day, day.AddDate(0, 0, 1).Add(time.Nanosecond), false, 0, 0)))
+		assert.Equal(t, []int64{5, 9}, taskIDs(c.ByDateRange(day.Add(time.Hour), day.AddDate(1, 0, 0), false, 0, 0)))
+		assert.Empty(t, c.ByDateRange(day.Add(time.Hour), day.AddDate(0, 0, 1), false, 0, 0))
+
+		assert.Equal(t, []int64{1, 5}, taskIDs(c.Page(0, 2)))
+		assert.Equal(t, []int64{5, 9}, taskIDs(c.Page(1, 0)))
+		assert.Equal(t, []int64{9}, taskIDs(c.Page(5, 1)))
+		assert.Empty(t, c.Page(9, 10))
+		assert.Equal(t, []int64{5}, taskIDs(c.ByStatus(false, 0, 1)))
+		assert.Equal(t, []int64{9}, taskIDs(c.ByStatus(false, 5, 1)))
+		assert.Equal(t, []int64{5}, taskIDs(c.ByDateRange(day, day.AddDate(1, 0, 0), false, 0, 1)))
+		assert.Equal(t, []int64{9}, taskIDs(c.ByDateRange(day, day.AddDate(1, 0, 0), false, 5, 1)))
+	}
+}
+
+func taskIDs(tasks []*model.Task) []int64 {
+	ids := make([]int64, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	return ids
+}
@@ -0,0 +1,32 @@
+package test
+
+import (
+	"Sber/app/pkg/config"
+	"Sber/app/pkg/logger"
+	postgres "Sber/app/pkg/storage"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestConnectWithRetryGivesUp(t *testing.T) {
+	var cfg config.Config
+	cfg.PostgreSQL.DSN = "postgres://user@127.0.0.1:1/db?connect_timeout=1"
+	cfg.PostgreSQL.ConnectRetries = 2
+	cfg.PostgreSQL.ConnectionTimeout = 1
+
+	dbPool, err := postgres.ConnectWithRetry(context.Background(), cfg, logger.GetLogger())
+	assert.Nil(t, dbPool)
+	assert.ErrorContains(t, err, "giving up after 3 attempts")
+}
+
+func TestConnectWithRetryRejectsInvalidDSN(t *testing.T) {
+	var cfg config.Config
+	cfg.PostgreSQL.DSN = "postgres://%zz"
+	cfg.PostgreSQL.ConnectRetries = 2
+	cfg.PostgreSQL.ConnectBackoff = 60
+
+	dbPool, err := postgres.ConnectWithRetry(context.Background(), cfg, logger.GetLogger())
+	assert.Nil(t, dbPool)
+	assert.ErrorContains(t, err, "cannot parse database config")
+}
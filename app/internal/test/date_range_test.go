@@ -0,0 +1,78 @@
+package test
+
+import (
+	"Sber/app/internal/task"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDateRangeQuery(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		Input    task.DateRangeQuery
+		Expected task.DateRange
+	}{
+		{
+			Input: task.DateRangeQuery{Date: "2023-09-21"},
+			Expected: task.DateRange{
+				From: time.Date(2023, 9, 21, 0, 0, 0, 0, time.UTC),
+				To:   time.Date(2023, 9, 22, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			Input: task.DateRangeQuery{Date: "2023-09-21T23:30:00Z", TimeZone: "Europe/Moscow"},
+			Expected: task.DateRange{
+				From: time.Date(2023, 9, 22, 0, 0, 0, 0, moscow),
+				To:   time.Date(2023, 9, 23, 0, 0, 0, 0, moscow),
+			},
+		},
+		{
+			Input: task.DateRangeQuery{Date: "2023-09-24", Period: task.PeriodWeek, TimeZone: "Europe/Moscow"},
+			Expected: task.DateRange{
+				From: time.Date(2023, 9, 18, 0, 0, 0, 0, moscow),
+				To:   time.Date(2023, 9, 25, 0, 0, 0, 0, moscow),
+			},
+		},
+		{
+			Input: task.DateRangeQuery{Date: "2023-12-15", Period: task.PeriodMonth, TimeZone: "Europe/Moscow"},
+			Expected: task.DateRange{
+				From: time.Date(2023, 12, 1, 0, 0, 0, 0, moscow),
+				To:   time.Date(2024, 1, 1, 0, 0, 0, 0, moscow),
+			},
+		},
+		{
+			Input: task.DateRangeQuery{From: "2023-09-01", To: "2023-10-01T12:00", TimeZone: "Europe/Moscow"},
+			Expected: task.DateRange{
+				From: time.Date(2023, 9, 1, 0, 0, 0, 0, moscow),
+				To:   time.Date(2023, 10, 1, 12, 0, 0, 0, moscow),
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		dates, err := testCase.Input.Range()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, testCase.Expected.From.Equal(dates.From), "from of %+v", testCase.Input)
+		assert.True(t, testCase.Expected.To.Equal(dates.To), "to of %+v", testCase.Input)
+	}
+
+	for _, input := range []task.DateRangeQuery{
+		{},
+		{Date: "21.09.2023"},
+		{Date: "2023-09-21", Period: "year"},
+		{Date: "2023-09-21", TimeZone: "Mars/Olympus"},
+		{From: "2023-09-01"},
+		{From: "2023-10-01", To: "2023-09-01"},
+		{Date: "2023-09-21", From: "2023-09-01", To: "2023-10-01"},
+	} {
+		_, err := input.Range()
+		assert.Error(t, err, "%+v", input)
+	}
+}
@@ -0,0 +1,62 @@
+package test
+
+import (
+	"Sber/app/internal/cache"
+	"Sber/app/internal/model"
+	"Sber/app/internal/task"
+	"Sber/app/internal/task/mocks"
+	"Sber/app/pkg/logger"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConditionalGet(t *testing.T) {
+	router := httprouter.New()
+	c := cache.NewCache()
+	updatedAt := time.Date(2023, 9, 20, 8, 30, 0, 0, time.UTC)
+	c.Set(&model.Task{ID: 1, Title: "Задача 1", UpdatedAt: updatedAt, Version: 3})
+	c.SetComplete(true)
+	handler := task.NewHandler(logger.GetLogger(), new(mocks.Service), c)
+	handler.Register(router)
+
+	get := func(url string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	recorder := get("/task/1", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `"3"`, recorder.Header().Get("ETag"))
+	assert.Equal(t, "Wed, 20 Sep 2023 08:30:00 GMT", recorder.Header().Get("Last-Modified"))
+
+	recorder = get("/task/1", http.Header{"If-None-Match": {`"2", "3"`}})
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+	assert.Empty(t, recorder.Body.String())
+
+	recorder = get("/task/1", http.Header{"If-Modified-Since": {"Wed, 20 Sep 2023 08:30:00 GMT"}})
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+	recorder = get("/task/1", http.Header{"If-Modified-Since": {"Wed, 20 Sep 2023 08:29:59 GMT"}})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = get("/task_all", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	etag := recorder.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	recorder = get("/task_all", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+
+	c.Set(&model.Task{ID: 2, Title: "Задача 2"})
+	recorder = get("/task_all", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusOK, recorder.Code, "a changed list must be sent again")
+	assert.NotEqual(t, etag, recorder.Header().Get("ETag"))
+}
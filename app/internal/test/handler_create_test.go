@@ -108,5 +108,13 @@ func TestCreateTask(t *testing.T) {
 		}
 		assert.Equal(t, expectedTask, &createdTask)
 		serviceMock.AssertExpectations(t)
+
+		token := recorder.Header().Get("X-Last-Write")
+		assert.NotEmpty(t, token, "the client gets a token to read its own write")
+		cookies := recorder.Result().Cookies()
+		if assert.Len(t, cookies, 1) {
+			assert.Equal(t, "last_write", cookies[0].Name)
+			assert.Equal(t, token, cookies[0].Value)
+		}
 	}
 }
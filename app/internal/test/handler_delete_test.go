@@ -38,7 +38,7 @@ func TestDeleteTask(t *testing.T) {
 			t.Fatal(err)
 		}
 		recorder := httptest.NewRecorder()
-		serviceMock.On("Delete", mock.AnythingOfType("int64")).Return(testCase.ExpectedErr).Once()
+		serviceMock.On("Delete", mock.Anything, mock.AnythingOfType("int64")).Return(testCase.ExpectedErr).Once()
 		router.ServeHTTP(recorder, req)
 		if testCase.ExpectedErr == nil {
 			assert.Equal(t, http.StatusOK, recorder.Code)
@@ -2,6 +2,7 @@ package test
 
 import (
 	"Sber/app/internal/cache"
+	"Sber/app/internal/model"
 	"Sber/app/internal/task"
 	"Sber/app/internal/task/mocks"
 	"Sber/app/pkg/logger"
@@ -52,3 +53,26 @@ func TestFindByIdTask(t *testing.T) {
 		serviceMock.AssertExpectations(t)
 	}
 }
+
+func TestFindByIdKeepsNewerCachedTask(t *testing.T) {
+	router := httprouter.New()
+	serviceMock := new(mocks.Service)
+	c := cache.NewCache()
+	handler := task.NewHandler(logger.GetLogger(), serviceMock, c)
+	handler.Register(router)
+
+	// An update lands in the cache while the read of the old row is under way.
+	serviceMock.On("GetById", mock.Anything, int64(1)).
+		Run(func(mock.Arguments) { c.Set(&model.Task{ID: 1, Title: "Новая", Version: 2}) }).
+		Return(&task.Task{ID: 1, Title: "Старая", Version: 1}, nil).Once()
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/task/1", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	cached, ok := c.Get(1)
+	if assert.True(t, ok) {
+		assert.Equal(t, int64(2), cached.Version, "a stale read does not overwrite the cache")
+	}
+	serviceMock.AssertExpectations(t)
+}
@@ -0,0 +1,189 @@
+package test
+
+import (
+	"Sber/app/internal/cache"
+	"Sber/app/internal/model"
+	"Sber/app/internal/task"
+	"Sber/app/internal/task/mocks"
+	"Sber/app/pkg/logger"
+	"bytes"
+	"encoding/json"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFindAllTasks(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Cached      []*model.Task
+		Complete    bool
+		FromService *[]task.Task
+		ExpectedIDs []int64
+	}{
+		{
+			Name:        "complete cache",
+			Cached:      []*model.Task{{ID: 2}, {ID: 1}},
+			Complete:    true,
+			ExpectedIDs: []int64{1, 2},
+		},
+		{
+			Name:        "complete empty cache",
+			Complete:    true,
+			ExpectedIDs: []int64{},
+		},
+		{
+			Name:        "incomplete cache",
+			Cached:      []*model.Task{{ID: 2}},
+			FromService: &[]task.Task{{ID: 1}, {ID: 2}, {ID: 3}},
+			ExpectedIDs: []int64{1, 2, 3},
+		},
+		{
+			Name:        "incomplete cache, no tasks",
+			FromService: &[]task.Task{},
+			ExpectedIDs: []int64{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		router := httprouter.New()
+		serviceMock := new(mocks.Service)
+		c := cache.NewCache()
+		for _, cached := range testCase.Cached {
+			c.Set(cached)
+		}
+		c.SetComplete(testCase.Complete)
+		handler := task.NewHandler(logger.GetLogger(), serviceMock, c)
+		handler.Register(router)
+
+		if testCase.FromService != nil {
+			serviceMock.On("FindAll", mock.Anything, task.Page{Limit: 100}).Return(testCase.FromService, nil).Once()
+		}
+
+		req, err := http.NewRequest("GET", "/task_all", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code, testCase.Name)
+
+		var tasks []task.Task
+		err = json.NewDecoder(recorder.Body).Decode(&tasks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make([]int64, 0)
+		for _, tk := range tasks {
+			ids = append(ids, tk.ID)
+		}
+		assert.Equal(t, testCase.ExpectedIDs, ids, testCase.Name)
+		serviceMock.AssertExpectations(t)
+	}
+}
+
+func TestFindAllStatusTasksFromCache(t *testing.T) {
+	router := httprouter.New()
+	serviceMock := new(mocks.Service)
+	c := cache.NewCache()
+	c.Set(&model.Task{ID: 1, Status: true})
+	c.Set(&model.Task{ID: 2, Status: false})
+	c.SetComplete(true)
+	handler := task.NewHandler(logger.GetLogger(), serviceMock, c)
+	handler.Register(router)
+
+	for _, status := range []bool{true, false} {
+		requestBody, err := json.Marshal(map[string]bool{"status": status})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := http.NewRequest("POST", "/task_all_status", bytes.NewReader(requestBody))
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		var tasks []task.Task
+		err = json.NewDecoder(recorder.Body).Decode(&tasks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Len(t, tasks, 1)
+		assert.Equal(t, status, tasks[0].Status)
+	}
+	serviceMock.AssertExpectations(t)
+}
+
+func TestFindAllTasksPaginated(t *testing.T) {
+	router := httprouter.New()
+	c := cache.NewCache()
+	for id := int64(1); id <= 5; id++ {
+		c.Set(&model.Task{ID: id})
+	}
+	c.SetComplete(true)
+	handler := task.NewHandler(logger.GetLogger(), new(mocks.Service), c)
+	handler.Register(router)
+
+	pages := make([][]int64, 0)
+	url := "/task_all?limit=2"
+	for url != "" {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, url, nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		var tasks []task.Task
+		if err := json.NewDecoder(recorder.Body).Decode(&tasks); err != nil {
+			t.Fatal(err)
+		}
+		ids := make([]int64, 0)
+		for _, tk := range tasks {
+			ids = append(ids, tk.ID)
+		}
+		pages = append(pages, ids)
+
+		url = ""
+		if link := recorder.Header().Get("Link"); link != "" {
+			assert.True(t, strings.HasSuffix(link, `>; rel="next"`))
+			url = strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`)
+		}
+	}
+	assert.Equal(t, [][]int64{{1, 2}, {3, 4}, {5}}, pages)
+
+	pages = pages[:0]
+	cursor := ""
+	for {
+		url := "/task_all_status?limit=2"
+		if cursor != "" {
+			url += "&cursor=" + cursor
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, url, strings.NewReader(`{"status": false}`)))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Empty(t, recorder.Header().Get("Link"), "a link would lose the filter in the body")
+
+		var tasks []task.Task
+		if err := json.NewDecoder(recorder.Body).Decode(&tasks); err != nil {
+			t.Fatal(err)
+		}
+		ids := make([]int64, 0)
+		for _, tk := range tasks {
+			ids = append(ids, tk.ID)
+		}
+		pages = append(pages, ids)
+
+		if cursor = recorder.Header().Get("X-Next-Cursor"); cursor == "" {
+			break
+		}
+	}
+	assert.Equal(t, [][]int64{{1, 2}, {3, 4}, {5}}, pages)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/task_all?cursor=!!", nil))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
@@ -1,6 +1,7 @@
 package test
 
 import (
+	"Sber/app/internal/apperror"
 	"Sber/app/internal/cache"
 	"Sber/app/internal/task"
 	"Sber/app/internal/task/mocks"
@@ -152,3 +153,39 @@ func TestUpdateTask(t *testing.T) {
 		serviceMock.AssertExpectations(t)
 	}
 }
+
+func TestUpdateTaskIfMatch(t *testing.T) {
+	router := httprouter.New()
+	serviceMock := new(mocks.Service)
+	handler := task.NewHandler(logger.GetLogger(), serviceMock, cache.NewCache())
+	handler.Register(router)
+
+	input := task.Task{ID: 1, Title: "Обновленная задача 1"}
+	requestBody, err := json.Marshal(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stale := input
+	stale.Version = 3
+	serviceMock.On("Update", mock.Anything, &stale).Return(nil, apperror.ErrVersionMismatch).Once()
+	req := httptest.NewRequest(http.MethodPut, "/task/1", bytes.NewReader(requestBody))
+	req.Header.Set("If-Match", `"3"`)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+
+	current := input
+	current.Version = 4
+	updated := current
+	updated.Version = 5
+	serviceMock.On("Update", mock.Anything, &current).Return(&updated, nil).Once()
+	req = httptest.NewRequest(http.MethodPut, "/task/1", bytes.NewReader(requestBody))
+	req.Header.Set("If-Match", `"4"`)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `"5"`, recorder.Header().Get("ETag"))
+
+	serviceMock.AssertExpectations(t)
+}
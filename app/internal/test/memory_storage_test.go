@@ -0,0 +1,350 @@
+package test
+
+import (
+	"Sber/app/internal/apperror"
+	"Sber/app/internal/cache"
+	"Sber/app/internal/task"
+	"Sber/app/pkg/config"
+	"Sber/app/pkg/logger"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	ctx := context.Background()
+	storage := task.NewMemoryStorage(cache.NewCache(), config.CacheWriteThrough)
+	date := time.Date(2023, 9, 20, 10, 0, 0, 0, time.UTC)
+
+	for i := 1; i <= 3; i++ {
+		created, err := storage.Create(ctx, &task.Task{
+			Title:       "Заголовок задачи",
+			Description: "Описание задачи",
+			Date:        date,
+			Status:      i%2 == 0,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, int64(i), created.ID)
+	}
+
+	found, err := storage.FindById(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, found.Status)
+
+	_, err = storage.FindById(ctx, 42)
+	assert.ErrorIs(t, err, apperror.ErrEmptyString)
+
+	tasks, err := storage.FindAllStatus(ctx, false, task.Page{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, tasks, 2)
+	assert.Equal(t, int64(1), tasks[0].ID)
+	assert.Equal(t, int64(3), tasks[1].ID)
+
+	tasks, err = storage.FindDateAllAvailable(ctx, task.DateRange{From: date, To: date.Add(time.Hour)}, true, task.Page{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, tasks, 1)
+
+	title := "Обновленный заголовок"
+	updated, err := storage.PartiallyUpdate(ctx, &task.PartiallyUpdateTask{ID: 1, Title: &title})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, title, updated.Title)
+	assert.Equal(t, "Описание задачи", updated.Description)
+	assert.Equal(t, date, updated.Date)
+
+	unchanged, err := storage.PartiallyUpdate(ctx, &task.PartiallyUpdateTask{ID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, updated.Version, unchanged.Version, "an empty patch keeps the version")
+	assert.Equal(t, updated.UpdatedAt, unchanged.UpdatedAt)
+
+	_, err = storage.Update(ctx, &task.Task{ID: 42})
+	assert.ErrorIs(t, err, apperror.ErrEmptyString)
+
+	assert.NoError(t, storage.Delete(ctx, 3))
+	assert.ErrorIs(t, storage.Delete(ctx, 3), apperror.ErrEmptyString)
+
+	created, err := storage.Create(ctx, &task.Task{Title: "Новая задача", Date: date})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(4), created.ID, "deleted IDs must not be reused")
+}
+
+func TestMemoryStorageConcurrentCreate(t *testing.T) {
+	ctx := context.Background()
+	storage := task.NewMemoryStorage(cache.NewCache(), config.CacheWriteThrough)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := storage.Create(ctx, &task.Task{Title: "Задача"})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	tasks, err := storage.FindAll(ctx, task.Page{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, tasks, 50)
+	for i, tk := range tasks {
+		assert.Equal(t, int64(i+1), tk.ID)
+	}
+}
+
+func TestServiceUpdateReleasesTransaction(t *testing.T) {
+	ctx := context.Background()
+	storage := task.NewMemoryStorage(cache.NewCache(), config.CacheWriteThrough)
+	service := task.NewService(storage, logger.GetLogger())
+
+	_, err := service.Update(ctx, &task.Task{ID: 7, Title: "Нет такой задачи"})
+	assert.ErrorIs(t, err, apperror.ErrEmptyString)
+
+	created, err := service.Create(ctx, &task.CreateTask{Title: "Задача"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		title := "Обновлено"
+		updated, err := service.PartiallyUpdate(ctx, &task.PartiallyUpdateTask{ID: created.ID, Title: &title})
+		assert.NoError(t, err)
+		assert.Equal(t, title, updated.Title)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a rolled back transaction must not block the next one")
+	}
+}
+
+func TestServiceUpdateChecksVersion(t *testing.T) {
+	ctx := context.Background()
+	service := task.NewService(task.NewMemoryStorage(cache.NewCache(), config.CacheWriteThrough), logger.GetLogger())
+
+	created, err := service.Create(ctx, &task.CreateTask{Title: "Задача"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(1), created.Version)
+
+	title := "Первая правка"
+	updated, err := service.PartiallyUpdate(ctx, &task.PartiallyUpdateTask{ID: created.ID, Title: &title, Version: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), updated.Version)
+
+	_, err = service.Update(ctx, &task.Task{ID: created.ID, Title: "Вторая правка", Version: 1})
+	assert.ErrorIs(t, err, apperror.ErrVersionMismatch)
+
+	updated, err = service.Update(ctx, &task.Task{ID: created.ID, Title: "Вторая правка"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), updated.Version)
+}
+
+func TestMemoryStorageTrash(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewCache()
+	storage := task.NewMemoryStorage(c, config.CacheWriteThrough)
+
+	for i := 1; i <= 2; i++ {
+		if _, err := storage.Create(ctx, &task.Task{Title: "Заголовок задачи"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.NoError(t, storage.Delete(ctx, 1))
+	assert.ErrorIs(t, storage.Delete(ctx, 1), apperror.ErrEmptyString)
+	_, err := storage.FindById(ctx, 1)
+	assert.ErrorIs(t, err, apperror.ErrEmptyString)
+	_, cached := c.Get(1)
+	assert.False(t, cached)
+
+	tasks, err := storage.FindAll(ctx, task.Page{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, tasks, 1)
+
+	trash, err := storage.FindDeleted(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, trash, 1)
+	assert.NotNil(t, trash[0].DeletedAt)
+
+	restored, err := storage.Restore(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, restored.DeletedAt)
+	_, cached = c.Get(1)
+	assert.True(t, cached)
+	_, err = storage.Restore(ctx, 2)
+	assert.ErrorIs(t, err, apperror.ErrEmptyString, "only tasks in the trash can be restored")
+
+	assert.NoError(t, storage.Delete(ctx, 2))
+	purged, err := storage.Purge(ctx, time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Zero(t, purged, "recently deleted tasks are kept")
+	purged, err = storage.Purge(ctx, time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	_, err = storage.Restore(ctx, 2)
+	assert.ErrorIs(t, err, apperror.ErrEmptyString)
+}
+
+func TestServiceRecordsHistory(t *testing.T) {
+	ctx := task.WithActor(context.Background(), "ivanov")
+	storage := task.NewMemoryStorage(cache.NewCache(), config.CacheWriteThrough)
+	service := task.NewService(storage, logger.GetLogger())
+
+	created, err := service.Create(ctx, &task.CreateTask{Title: "Задача", Description: "Описание"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := true
+	_, err = service.PartiallyUpdate(ctx, &task.PartiallyUpdateTask{ID: created.ID, Status: &status})
+	assert.NoError(t, err)
+	_, err = service.PartiallyUpdate(ctx, &task.PartiallyUpdateTask{ID: created.ID, Status: &status})
+	assert.NoError(t, err)
+	assert.NoError(t, service.Delete(ctx, created.ID))
+
+	history, err := service.History(ctx, created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := *history
+	assert.Len(t, entries, 3, "an update that changes nothing is not recorded")
+	assert.Equal(t, task.HistoryCreate, entries[0].Op)
+	assert.Len(t, entries[0].Changes, 4)
+	assert.Equal(t, task.HistoryUpdate, entries[1].Op)
+	assert.Equal(t, []task.FieldChange{{Field: "status", Old: false, New: true}}, entries[1].Changes)
+	assert.Equal(t, task.HistoryDelete, entries[2].Op)
+	assert.Equal(t, "ivanov", entries[2].Actor)
+
+	_, err = storage.Purge(ctx, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	history, err = service.History(ctx, created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, *history, 3, "history outlives purged tasks")
+
+	untracked, err := storage.Create(ctx, &task.Task{Title: "Задача без истории"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	history, err = service.History(ctx, untracked.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, *history)
+
+	_, err = service.History(ctx, 42)
+	assert.ErrorIs(t, err, apperror.ErrEmptyString)
+}
+
+func TestServiceBulk(t *testing.T) {
+	ctx := context.Background()
+	service := task.NewService(task.NewMemoryStorage(cache.NewCache(), config.CacheWriteThrough), logger.GetLogger())
+
+	results, err := service.CreateMany(ctx, []task.CreateTask{{Title: "Задача 1"}, {Title: "Задача 2"}, {Title: "Задача 3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, results, 3)
+	for i, result := range results {
+		assert.Equal(t, http.StatusCreated, result.Status)
+		assert.Equal(t, int64(i+1), result.ID)
+	}
+
+	status := true
+	patch := func(id, version int64) task.BulkUpdateTask {
+		return task.BulkUpdateTask{PartiallyUpdateTask: task.PartiallyUpdateTask{ID: id, Status: &status}, Version: version}
+	}
+	results, err = service.PartiallyUpdateMany(ctx, []task.BulkUpdateTask{patch(1, 1), patch(2, 7), patch(42, 0), patch(1, 0), patch(0, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := make([]int, 0, len(results))
+	for _, result := range results {
+		statuses = append(statuses, result.Status)
+	}
+	assert.Equal(t, []int{http.StatusOK, http.StatusPreconditionFailed, http.StatusNotFound, http.StatusBadRequest, http.StatusBadRequest}, statuses)
+	assert.True(t, results[0].Task.Status)
+	assert.NotEmpty(t, results[1].Error)
+
+	results, err = service.DeleteMany(ctx, []int64{2, 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusOK, results[0].Status)
+	assert.Equal(t, http.StatusNotFound, results[1].Status)
+
+	tasks, err := service.FindAll(ctx, task.Page{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, *tasks, 2)
+	history, err := service.History(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, *history, 2)
+}
+
+func TestMemoryStorageSearch(t *testing.T) {
+	ctx := context.Background()
+	storage := task.NewMemoryStorage(cache.NewCache(), config.CacheWriteThrough)
+	for _, input := range []task.Task{
+		{Title: "Купить молоко", Description: "Зайти в магазин"},
+		{Title: "Deploy release", Description: "Купить домен для релиза"},
+		{Title: "Позвонить маме"},
+	} {
+		input := input
+		if _, err := storage.Create(ctx, &input); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := storage.Search(ctx, "купить", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, results, 2)
+	assert.Equal(t, int64(1), results[0].ID, "title matches rank higher")
+	assert.Equal(t, "<b>Купить</b> молоко", results[0].TitleHighlight)
+	assert.Equal(t, "<b>Купить</b> домен для релиза", results[1].Snippet)
+
+	results, err = storage.Search(ctx, "купить deploy", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, results, 1, "every word has to match")
+
+	if _, err = storage.Create(ctx, &task.Task{Title: `<img src=x onerror="alert(1)"> хлеб & соль`}); err != nil {
+		t.Fatal(err)
+	}
+	results, err = storage.Search(ctx, "хлеб", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, results, 1)
+	assert.Equal(t, `&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <b>хлеб</b> &amp; соль`, results[0].TitleHighlight)
+}
@@ -0,0 +1,54 @@
+package test
+
+import (
+	"Sber/app/pkg/migrate"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0002_add_index.up.sql":     {Data: []byte("CREATE INDEX task_status_idx ON Task (status);")},
+		"migrations/0002_add_index.down.sql":   {Data: []byte("DROP INDEX task_status_idx;")},
+		"migrations/0001_create_task.up.sql":   {Data: []byte("CREATE TABLE Task (id serial primary key);")},
+		"migrations/0001_create_task.down.sql": {Data: []byte("DROP TABLE Task;")},
+	}
+
+	migrations, err := migrate.Load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, migrations, 2)
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "create_task", migrations[0].Name)
+	assert.Equal(t, "DROP TABLE Task;", migrations[0].Down)
+	assert.Equal(t, int64(2), migrations[1].Version)
+	assert.Equal(t, "add_index", migrations[1].Name)
+
+	testCases := []struct {
+		Name string
+		FS   fstest.MapFS
+	}{
+		{
+			Name: "bad file name",
+			FS:   fstest.MapFS{"migrations/create_task.sql": {Data: []byte("SELECT 1;")}},
+		},
+		{
+			Name: "missing up script",
+			FS:   fstest.MapFS{"migrations/0001_create_task.down.sql": {Data: []byte("DROP TABLE Task;")}},
+		},
+		{
+			Name: "conflicting names",
+			FS: fstest.MapFS{
+				"migrations/0001_create_task.up.sql":   {Data: []byte("SELECT 1;")},
+				"migrations/0001_create_list.down.sql": {Data: []byte("SELECT 1;")},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		_, err = migrate.Load(testCase.FS)
+		assert.Error(t, err, testCase.Name)
+	}
+}
@@ -0,0 +1,111 @@
+package test
+
+import (
+	"Sber/app/internal/cache"
+	"Sber/app/internal/model"
+	"Sber/app/internal/task"
+	"Sber/app/internal/task/mocks"
+	"Sber/app/pkg/logger"
+	"encoding/json"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseQueryRejectsInvalidInput(t *testing.T) {
+	for _, raw := range []string{
+		"filter=status",
+		"filter=colour:eq:red",
+		"filter=status:contains:true",
+		"filter=date:gte:yesterday",
+		"sort=-priority",
+		"sort=date,date",
+		"limit=0",
+		"cursor=!!",
+	} {
+		values, err := url.ParseQuery(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = task.ParseQuery(values)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestQueryApply(t *testing.T) {
+	day := time.Date(2023, 9, 20, 0, 0, 0, 0, time.UTC)
+	tasks := []*model.Task{
+		{ID: 1, Title: "Купить молоко", Date: day, Status: false},
+		{ID: 2, Title: "Позвонить маме", Date: day.AddDate(0, 0, 1), Status: false},
+		{ID: 3, Title: "Купить хлеб", Date: day.AddDate(0, 0, 1), Status: true},
+		{ID: 4, Title: "купить сыр", Date: day.AddDate(0, 0, 2), Status: false},
+	}
+
+	values := url.Values{
+		"filter": {"status:eq:false", "title:contains:КУПИТЬ", "date:gte:2023-09-20T00:00:00Z"},
+		"sort":   {"-date"},
+	}
+	q, err := task.ParseQuery(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []int64{4, 1}, ids(q.Apply(tasks)))
+
+	values = url.Values{"sort": {"-date,title"}, "limit": {"2"}}
+	q, err = task.ParseQuery(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := q.Apply(tasks)
+	assert.Equal(t, []int64{4, 3}, ids(page))
+
+	values.Set("cursor", q.Cursor(page[len(page)-1]))
+	q, err = task.ParseQuery(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []int64{2, 1}, ids(q.Apply(tasks)))
+
+	values.Set("sort", "date")
+	_, err = task.ParseQuery(values)
+	assert.Error(t, err, "a cursor is bound to the sort it was issued for")
+}
+
+func TestFindTasksFromCache(t *testing.T) {
+	router := httprouter.New()
+	c := cache.NewCache()
+	for id := int64(1); id <= 5; id++ {
+		c.Set(&model.Task{ID: id, Status: id%2 == 1})
+	}
+	c.SetComplete(true)
+	task.NewHandler(logger.GetLogger(), new(mocks.Service), c).Register(router)
+
+	pages := make([][]int64, 0)
+	next := "/tasks?filter=status:eq:true&sort=-id&limit=2"
+	for next != "" {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, next, nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		var found []*model.Task
+		if err := json.NewDecoder(recorder.Body).Decode(&found); err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, ids(found))
+		next = strings.TrimSuffix(strings.TrimPrefix(recorder.Header().Get("Link"), "<"), `>; rel="next"`)
+	}
+	assert.Equal(t, [][]int64{{5, 3}, {1}}, pages)
+}
+
+func ids(tasks []*model.Task) []int64 {
+	result := make([]int64, 0, len(tasks))
+	for _, tk := range tasks {
+		result = append(result, tk.ID)
+	}
+	return result
+}
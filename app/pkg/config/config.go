@@ -7,6 +7,14 @@ import (
 	"sync"
 )
 
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+
+	CacheWriteThrough    = "write-through"
+	CacheWriteInvalidate = "write-invalidate"
+)
+
 type Config struct {
 	HTTP struct {
 		Host         string `yaml:"host" env:"HTTP-HOST"`
@@ -15,11 +23,40 @@ type Config struct {
 		WriteTimeout int    `yaml:"write_timeout" env:"HTTP-WRITE-TIMEOUT"`
 	} `yaml:"http"`
 	PostgreSQL struct {
-		DSN               string `env:"DATABASE_DSN" env-required:"true"`
+		// DSN is required only with the postgres storage.
+		DSN               string `env:"DATABASE_DSN"`
+		ReplicaDSN        string `env:"DATABASE_REPLICA_DSN"`
+		ReadYourWrites    int    `yaml:"read_your_writes" env-default:"5"`
 		RequestTimeout    int    `yaml:"request_timeout" env-default:"5"`
 		ConnectionTimeout int    `yaml:"connection_timeout" env-default:"10"`
+		ConnectRetries    int    `yaml:"connect_retries" env-default:"10"`
+		ConnectBackoff    int    `yaml:"connect_backoff" env-default:"1"`
+		ConnectMaxBackoff int    `yaml:"connect_max_backoff" env-default:"30"`
 		ShutdownTimeout   int    `yaml:"shutdown_timeout" env-default:"5"`
-	} `yaml:"postgresql" env-required:"true"`
+		MaxConns          int32  `yaml:"max_conns" env-default:"10"`
+		MinConns          int32  `yaml:"min_conns" env-default:"0"`
+		MaxConnIdleTime   int    `yaml:"max_conn_idle_time" env-default:"1800"`
+		MaxConnLifetime   int    `yaml:"max_conn_lifetime" env-default:"3600"`
+		HealthCheckPeriod int    `yaml:"health_check_period" env-default:"60"`
+		StatementCache    int    `yaml:"statement_cache" env-default:"512"`
+		MigrateOnStart    bool   `yaml:"migrate_on_start" env-default:"true"`
+		BreakerThreshold  int    `yaml:"breaker_threshold" env-default:"5"`
+		BreakerCooldown   int    `yaml:"breaker_cooldown" env-default:"10"`
+	} `yaml:"postgresql"`
+	Cache struct {
+		MaxEntries       int    `yaml:"max_entries" env-default:"0"`
+		TTL              int    `yaml:"ttl" env-default:"0"`
+		ListenNotify     bool   `yaml:"listen_notify" env-default:"true"`
+		Policy           string `yaml:"policy" env-default:"write-through"`
+		SnapshotPath     string `yaml:"snapshot_path" env-default:""`
+		SnapshotInterval int    `yaml:"snapshot_interval" env-default:"300"`
+		WarmupPageSize   int    `yaml:"warmup_page_size" env-default:"1000"`
+	} `yaml:"cache"`
+	Storage struct {
+		Type           string `yaml:"type" env:"STORAGE_TYPE" env-default:"postgres"`
+		TrashRetention int    `yaml:"trash_retention" env-default:"30"`
+		PurgeInterval  int    `yaml:"purge_interval" env-default:"3600"`
+	} `yaml:"storage"`
 }
 
 var cfg Config
@@ -0,0 +1,284 @@
+package migrate
+
+import (
+	"Sber/app/pkg/logger"
+	"context"
+	"embed"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// lockKey identifies the advisory lock held while migrations are applied,
+// so that several instances starting at once do not race each other.
+const lockKey int64 = 0x5be27a5c
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+type Migrator struct {
+	log        logger.Logger
+	pool       *pgxpool.Pool
+	migrations []Migration
+	dryRun     bool
+}
+
+// NewMigrator returns a Migrator for the migrations embedded into the binary.
+// In dry-run mode pending migrations are only logged, nothing is executed.
+func NewMigrator(pool *pgxpool.Pool, log logger.Logger, dryRun bool) (*Migrator, error) {
+	migrations, err := Load(migrationsFS)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		log:        log,
+		pool:       pool,
+		migrations: migrations,
+		dryRun:     dryRun,
+	}, nil
+}
+
+// Load reads "<version>_<name>.up.sql" / "<version>_<name>.down.sql" pairs from the
+// migrations directory of fsys and returns them ordered by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("cannot read migrations: %v", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file name %q", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q: %v", entry.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("cannot read migration %q: %v", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Up applies every pending migration in version order.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withConn(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		pending := 0
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			pending++
+			if m.dryRun {
+				m.log.Infof("MIGRATE (dry-run): would apply %d_%s:\n%s", migration.Version, migration.Name, migration.Up)
+				continue
+			}
+			if err = m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+			m.log.Infof("MIGRATE: applied %d_%s", migration.Version, migration.Name)
+		}
+		if pending == 0 {
+			m.log.Info("MIGRATE: schema is up to date")
+		}
+		return nil
+	})
+}
+
+// Down rolls back the last steps applied migrations.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	return m.withConn(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			steps--
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s cannot be rolled back: no down script", migration.Version, migration.Name)
+			}
+			if m.dryRun {
+				m.log.Infof("MIGRATE (dry-run): would roll back %d_%s:\n%s", migration.Version, migration.Name, migration.Down)
+				continue
+			}
+			if err = m.revert(ctx, conn, migration); err != nil {
+				return err
+			}
+			m.log.Infof("MIGRATE: rolled back %d_%s", migration.Version, migration.Name)
+		}
+		return nil
+	})
+}
+
+// Status reports every known migration together with whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot acquire connection: %v", err)
+	}
+	defer conn.Release()
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// withConn runs fn on a dedicated connection holding the migration advisory lock.
+func (m *Migrator) withConn(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot acquire connection: %v", err)
+	}
+	defer conn.Release()
+
+	if m.dryRun {
+		return fn(conn)
+	}
+
+	if _, err = conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("cannot acquire migration lock: %v", err)
+	}
+	defer func() {
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, lockKey); err != nil {
+			m.log.Error("failed to release migration lock:", err)
+		}
+	}()
+
+	if _, err = conn.Exec(ctx,
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+			 version    bigint       primary key,
+			 name       text         not null,
+			 applied_at timestamptz  not null default now()
+		 )`); err != nil {
+		return fmt.Errorf("cannot create schema_migrations table: %v", err)
+	}
+	return fn(conn)
+}
+
+// applied returns the applied migration versions with their application time.
+func (m *Migrator) applied(ctx context.Context, conn *pgxpool.Conn) (map[int64]time.Time, error) {
+	applied := make(map[int64]time.Time)
+
+	var exists bool
+	if err := conn.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("cannot check schema_migrations table: %v", err)
+	}
+	if !exists {
+		return applied, nil
+	}
+
+	rows, err := conn.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read schema_migrations: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("cannot read schema_migrations: %v", err)
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *pgxpool.Conn, migration Migration) error {
+	return conn.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, migration.Up); err != nil {
+			return fmt.Errorf("failed to apply migration %d_%s: %v", migration.Version, migration.Name, err)
+		}
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`,
+			migration.Version, migration.Name); err != nil {
+			return fmt.Errorf("failed to record migration %d_%s: %v", migration.Version, migration.Name, err)
+		}
+		return nil
+	})
+}
+
+func (m *Migrator) revert(ctx context.Context, conn *pgxpool.Conn, migration Migration) error {
+	return conn.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, migration.Down); err != nil {
+			return fmt.Errorf("failed to roll back migration %d_%s: %v", migration.Version, migration.Name, err)
+		}
+		if _, err := tx.Exec(ctx,
+			`DELETE FROM schema_migrations WHERE version = $1`, migration.Version); err != nil {
+			return fmt.Errorf("failed to unrecord migration %d_%s: %v", migration.Version, migration.Name, err)
+		}
+		return nil
+	})
+}
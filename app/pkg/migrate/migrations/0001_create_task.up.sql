@@ -1,5 +1,3 @@
-DROP TABLE IF EXISTS Task;
-
 CREATE TABLE IF NOT EXISTS Task (
  id              serial       primary key,
  title           text         not null,
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS task_notify ON Task;
+
+DROP FUNCTION IF EXISTS notify_task_change();
@@ -0,0 +1,16 @@
+CREATE OR REPLACE FUNCTION notify_task_change() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM pg_notify('task_changes', json_build_object('op', TG_OP, 'id', OLD.id)::text);
+        RETURN OLD;
+    END IF;
+    PERFORM pg_notify('task_changes', json_build_object('op', TG_OP, 'id', NEW.id)::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS task_notify ON Task;
+
+CREATE TRIGGER task_notify
+    AFTER INSERT OR UPDATE OR DELETE ON Task
+    FOR EACH ROW EXECUTE FUNCTION notify_task_change();
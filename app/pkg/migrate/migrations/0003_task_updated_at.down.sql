@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS task_touch_updated_at ON Task;
+
+DROP FUNCTION IF EXISTS touch_task_updated_at();
+
+DROP INDEX IF EXISTS task_updated_at_idx;
+
+ALTER TABLE Task DROP COLUMN IF EXISTS updated_at;
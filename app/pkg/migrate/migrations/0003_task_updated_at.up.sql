@@ -0,0 +1,16 @@
+ALTER TABLE Task ADD COLUMN IF NOT EXISTS updated_at timestamptz not null default now();
+
+CREATE INDEX IF NOT EXISTS task_updated_at_idx ON Task (updated_at);
+
+CREATE OR REPLACE FUNCTION touch_task_updated_at() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at = now();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS task_touch_updated_at ON Task;
+
+CREATE TRIGGER task_touch_updated_at
+    BEFORE UPDATE ON Task
+    FOR EACH ROW EXECUTE FUNCTION touch_task_updated_at();
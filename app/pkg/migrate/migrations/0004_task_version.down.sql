@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS task_bump_version ON Task;
+
+DROP FUNCTION IF EXISTS bump_task_version();
+
+ALTER TABLE Task DROP COLUMN IF EXISTS version;
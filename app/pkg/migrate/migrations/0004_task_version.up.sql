@@ -0,0 +1,14 @@
+ALTER TABLE Task ADD COLUMN IF NOT EXISTS version bigint not null default 1;
+
+CREATE OR REPLACE FUNCTION bump_task_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version = OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS task_bump_version ON Task;
+
+CREATE TRIGGER task_bump_version
+    BEFORE UPDATE ON Task
+    FOR EACH ROW EXECUTE FUNCTION bump_task_version();
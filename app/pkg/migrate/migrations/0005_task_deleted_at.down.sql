@@ -0,0 +1,5 @@
+DELETE FROM Task WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS task_deleted_at_idx;
+
+ALTER TABLE Task DROP COLUMN IF EXISTS deleted_at;
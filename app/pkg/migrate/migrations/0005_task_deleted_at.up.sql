@@ -0,0 +1,3 @@
+ALTER TABLE Task ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS task_deleted_at_idx ON Task (deleted_at) WHERE deleted_at IS NOT NULL;
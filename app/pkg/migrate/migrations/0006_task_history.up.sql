@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS task_history (
+    id         bigserial primary key,
+    task_id    bigint not null REFERENCES Task (id) ON DELETE CASCADE,
+    op         text not null,
+    changes    jsonb not null default '[]',
+    actor      text,
+    changed_at timestamptz not null default now()
+);
+
+CREATE INDEX IF NOT EXISTS task_history_task_id_idx ON task_history (task_id, id);
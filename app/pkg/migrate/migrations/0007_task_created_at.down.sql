@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS task_created_at_idx;
+
+ALTER TABLE Task DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,14 @@
+ALTER TABLE Task ADD COLUMN IF NOT EXISTS created_at timestamptz not null default now();
+
+-- Tasks created since history was recorded get their real creation time.
+-- Triggers are off for the backfill so that it keeps versions and updated_at
+-- and does not notify the other instances about every row.
+ALTER TABLE Task DISABLE TRIGGER USER;
+
+UPDATE Task SET created_at = h.changed_at
+    FROM task_history h
+    WHERE h.task_id = Task.id AND h.op = 'create';
+
+ALTER TABLE Task ENABLE TRIGGER USER;
+
+CREATE INDEX IF NOT EXISTS task_created_at_idx ON Task (created_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS task_search_idx;
+
+ALTER TABLE Task DROP COLUMN IF EXISTS search;
@@ -0,0 +1,4 @@
+DELETE FROM task_history h WHERE NOT EXISTS (SELECT 1 FROM Task WHERE id = h.task_id);
+
+ALTER TABLE task_history ADD CONSTRAINT task_history_task_id_fkey
+    FOREIGN KEY (task_id) REFERENCES Task (id) ON DELETE CASCADE;
@@ -0,0 +1,3 @@
+-- History is an audit trail: it outlives tasks purged from the trash, so it
+-- no longer references Task.
+ALTER TABLE task_history DROP CONSTRAINT IF EXISTS task_history_task_id_fkey;
@@ -2,29 +2,197 @@ package postgres
 
 import (
 	"Sber/app/pkg/config"
+	"Sber/app/pkg/logger"
 	"context"
 	"fmt"
-	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgconn/stmtcache"
+	"github.com/jackc/pgx/v4/pgxpool"
 	"time"
 )
 
-func ConnectDB(cfg config.Config) (*pgx.Conn, error) {
+// PoolStats is a snapshot of the connection pool state.
+type PoolStats struct {
+	TotalConns              int32         `json:"total_conns"`
+	AcquiredConns           int32         `json:"acquired_conns"`
+	IdleConns               int32         `json:"idle_conns"`
+	ConstructingConns       int32         `json:"constructing_conns"`
+	MaxConns                int32         `json:"max_conns"`
+	AcquireCount            int64         `json:"acquire_count"`
+	AcquireDuration         time.Duration `json:"acquire_duration"`
+	EmptyAcquireCount       int64         `json:"empty_acquire_count"`
+	CanceledAcquireCount    int64         `json:"canceled_acquire_count"`
+	MaxIdleDestroyCount     int64         `json:"max_idle_destroy_count"`
+	MaxLifetimeDestroyCount int64         `json:"max_lifetime_destroy_count"`
+}
+
+func ConnectDB(cfg config.Config) (*pgxpool.Pool, error) {
+	poolConfig, err := parseConfig(cfg, cfg.PostgreSQL.DSN)
+	if err != nil {
+		return nil, err
+	}
+	return connect(cfg, poolConfig)
+}
+
+// ConnectReplica connects to the read replica with the pool settings of the
+// primary. It returns a nil pool when no replica is configured.
+func ConnectReplica(cfg config.Config) (*pgxpool.Pool, error) {
+	if cfg.PostgreSQL.ReplicaDSN == "" {
+		return nil, nil
+	}
+	poolConfig, err := parseConfig(cfg, cfg.PostgreSQL.ReplicaDSN)
+	if err != nil {
+		return nil, err
+	}
+	return connect(cfg, poolConfig)
+}
 
-	pgxConfig, err := pgx.ParseConfig(cfg.PostgreSQL.DSN)
+// parseConfig builds the pool config for dsn from the pool settings of cfg.
+func parseConfig(cfg config.Config, dsn string) (*pgxpool.Config, error) {
+	poolConfig, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
 		return nil, fmt.Errorf("cannot parse database config from dsn %v", err)
 	}
 
+	if cfg.PostgreSQL.MaxConns > 0 {
+		poolConfig.MaxConns = cfg.PostgreSQL.MaxConns
+	}
+	poolConfig.MinConns = cfg.PostgreSQL.MinConns
+	poolConfig.MaxConnIdleTime = time.Duration(cfg.PostgreSQL.MaxConnIdleTime) * time.Second
+	poolConfig.MaxConnLifetime = time.Duration(cfg.PostgreSQL.MaxConnLifetime) * time.Second
+	poolConfig.HealthCheckPeriod = time.Duration(cfg.PostgreSQL.HealthCheckPeriod) * time.Second
+
+	// pgx already prepares the queries a connection runs and reuses them by
+	// name, with room for 512 statements; this only makes the capacity
+	// configurable, with 0 turning the cache off.
+	if capacity := cfg.PostgreSQL.StatementCache; capacity > 0 {
+		poolConfig.ConnConfig.BuildStatementCache = func(conn *pgconn.PgConn) stmtcache.Cache {
+			return stmtcache.New(conn, stmtcache.ModePrepare, capacity)
+		}
+	} else {
+		poolConfig.ConnConfig.BuildStatementCache = nil
+	}
+	return poolConfig, nil
+}
+
+func connect(cfg config.Config, poolConfig *pgxpool.Config) (*pgxpool.Pool, error) {
 	dbTimeout, dbCancel := context.WithTimeout(context.Background(), time.Duration(cfg.PostgreSQL.ConnectionTimeout)*time.Second)
 	defer dbCancel()
 
-	dbConn, err := pgx.ConnectConfig(dbTimeout, pgxConfig)
+	dbPool, err := pgxpool.ConnectConfig(dbTimeout, poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("cannot connect to database: %v", err)
 	}
 
-	if err = dbConn.Ping(dbTimeout); err != nil {
-		fmt.Errorf("cannot ping database: %v", err)
+	if err = dbPool.Ping(dbTimeout); err != nil {
+		dbPool.Close()
+		return nil, fmt.Errorf("cannot ping database: %v", err)
+	}
+	return dbPool, nil
+}
+
+// ConnectWithRetry connects like ConnectDB, retrying until it succeeds and
+// waiting between attempts with exponential backoff. It gives up after
+// ConnectRetries retries or when ctx is cancelled. A DSN that cannot be parsed
+// is not retried.
+func ConnectWithRetry(ctx context.Context, cfg config.Config, log logger.Logger) (*pgxpool.Pool, error) {
+	poolConfig, err := parseConfig(cfg, cfg.PostgreSQL.DSN)
+	if err != nil {
+		return nil, err
+	}
+	backoff := time.Duration(cfg.PostgreSQL.ConnectBackoff) * time.Second
+	maxBackoff := time.Duration(cfg.PostgreSQL.ConnectMaxBackoff) * time.Second
+
+	for attempt := 0; ; attempt++ {
+		dbPool, err := connect(cfg, poolConfig.Copy())
+		if err == nil {
+			return dbPool, nil
+		}
+		if attempt >= cfg.PostgreSQL.ConnectRetries {
+			return nil, fmt.Errorf("giving up after %d attempts: %v", attempt+1, err)
+		}
+
+		log.Warnf("POSTGRES: %v, retrying in %v", err, backoff)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// Watch pings the database every interval until ctx is cancelled. The pool
+// dials new connections on demand, so when the database comes back after a
+// restart it is enough to drop the idle connections that went stale.
+func Watch(ctx context.Context, dbPool *pgxpool.Pool, interval time.Duration, log logger.Logger) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lost := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, interval)
+		err := dbPool.Ping(pingCtx)
+		cancel()
+
+		switch {
+		case err != nil && ctx.Err() == nil:
+			if !lost {
+				log.Errorf("POSTGRES: database connection lost: %v", err)
+				lost = true
+			}
+			for _, conn := range dbPool.AcquireAllIdle(ctx) {
+				conn.Conn().Close(ctx)
+				conn.Release()
+			}
+		case err == nil && lost:
+			log.Info("POSTGRES: database connection restored")
+			lost = false
+		}
+	}
+}
+
+// Stats returns the current statistics of the pool.
+func Stats(dbPool *pgxpool.Pool) PoolStats {
+	s := dbPool.Stat()
+	return PoolStats{
+		TotalConns:              s.TotalConns(),
+		AcquiredConns:           s.AcquiredConns(),
+		IdleConns:               s.IdleConns(),
+		ConstructingConns:       s.ConstructingConns(),
+		MaxConns:                s.MaxConns(),
+		AcquireCount:            s.AcquireCount(),
+		AcquireDuration:         s.AcquireDuration(),
+		EmptyAcquireCount:       s.EmptyAcquireCount(),
+		CanceledAcquireCount:    s.CanceledAcquireCount(),
+		MaxIdleDestroyCount:     s.MaxIdleDestroyCount(),
+		MaxLifetimeDestroyCount: s.MaxLifetimeDestroyCount(),
+	}
+}
+
+// Close closes the pool, waiting at most timeout for acquired connections to be released.
+func Close(dbPool *pgxpool.Pool, timeout time.Duration) error {
+	done := make(chan struct{})
+	go func() {
+		dbPool.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("timed out after %v waiting for connections to be released", timeout)
 	}
-	return dbConn, nil
 }
@@ -34,6 +34,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/task.CreateTask"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Автор изменения для истории задачи",
+                        "name": "X-Actor",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -63,6 +69,18 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag, полученный ранее; 304, если задача не изменилась",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last-Modified, полученный ранее",
+                        "name": "If-Modified-Since",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -99,6 +117,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/task.Task"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag задачи; при несовпадении версии вернется 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Автор изменения для истории задачи",
+                        "name": "X-Actor",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -111,7 +141,7 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Удаляет задачу по заданному идентификатору",
+                "description": "Перемещает задачу с заданным идентификатором в корзину",
                 "consumes": [
                     "application/json"
                 ],
@@ -126,6 +156,12 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Автор изменения для истории задачи",
+                        "name": "X-Actor",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -162,6 +198,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/task.PartiallyUpdateTask"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag задачи; при несовпадении версии вернется 412",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Автор изменения для истории задачи",
+                        "name": "X-Actor",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -174,7 +222,74 @@ const docTemplate = `{
                 }
             }
         },
-        "/tasks": {
+        "/task/{id}/history": {
+            "get": {
+                "description": "Получает изменения задачи по полям, начиная с создания. История сохраняется и после окончательного удаления задачи из корзины; для задач, созданных до появления истории, список пуст",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Получить историю изменений задачи",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/task.HistoryEntry"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/task/{id}/restore": {
+            "post": {
+                "description": "Возвращает задачу из корзины",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Восстановить задачу",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Идентификатор задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Автор изменения для истории задачи",
+                        "name": "X-Actor",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/task.Task"
+                        }
+                    }
+                }
+            }
+        },
+        "/task_all": {
             "get": {
                 "description": "Получает список всех задач",
                 "consumes": [
@@ -184,6 +299,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "summary": "Получить все задачи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag, полученный ранее; 304, если список не изменился",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last-Modified, полученный ранее",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы, до 1000, по умолчанию 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы из заголовка Link",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -192,6 +333,196 @@ const docTemplate = `{
                             "items": {
                                 "$ref": "#/definitions/task.Task"
                             }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Ссылка на следующую страницу (rel=next)"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks": {
+            "get": {
+                "description": "Получает задачи, отвечающие всем условиям filter, в порядке sort. Условие имеет вид поле:оператор:значение; поля id, title, description, status, date, created_at, updated_at; операторы eq, ne, lt, lte, gt, gte и contains для строк. Время задается в RFC 3339.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Найти задачи",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Условия, например status:eq:false или date:gte:2023-09-01T00:00:00Z",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключи сортировки через запятую, - для обратного порядка, например -date,title",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы, до 1000, по умолчанию 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы из заголовка Link",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/task.Task"
+                            }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Ссылка на следующую страницу (rel=next)"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/bulk": {
+            "post": {
+                "description": "Создает задачи одной транзакцией и возвращает результат по каждой",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Создать несколько задач",
+                "parameters": [
+                    {
+                        "description": "Данные для создания задач",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/task.CreateTask"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Автор изменения для истории задачи",
+                        "name": "X-Actor",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/task.BulkResult"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Перемещает задачи в корзину одной транзакцией и возвращает результат по каждой",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Удалить несколько задач",
+                "parameters": [
+                    {
+                        "description": "Идентификаторы задач",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "integer"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Автор изменения для истории задачи",
+                        "name": "X-Actor",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/task.BulkResult"
+                            }
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Обновляет задачи одной транзакцией и возвращает результат по каждой; version задачи заменяет If-Match",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Частично обновить несколько задач",
+                "parameters": [
+                    {
+                        "description": "Идентификаторы и изменяемые поля задач",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/task.BulkUpdateTask"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Автор изменения для истории задачи",
+                        "name": "X-Actor",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/task.BulkResult"
+                            }
                         }
                     }
                 }
@@ -199,7 +530,7 @@ const docTemplate = `{
         },
         "/tasks/date": {
             "post": {
-                "description": "Получает список всех доступных задач по заданной дате",
+                "description": "Получает задачи с заданным статусом за календарный день, неделю или месяц, которым принадлежит date, либо за интервал от from до to (to не включается). Календарь и время без смещения читаются в часовом поясе time_zone, по умолчанию UTC.",
                 "consumes": [
                     "application/json"
                 ],
@@ -208,12 +539,70 @@ const docTemplate = `{
                 ],
                 "summary": "Получить все задачи по определенной дате",
                 "parameters": [
+                    {
+                        "description": "Дата или интервал, часовой пояс и статус задач",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/task.DateRangeQuery"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы, до 1000, по умолчанию 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
-                        "description": "Запрос на получение задач с определенной датой и статусом",
-                        "name": "date",
+                        "description": "Курсор следующей страницы из заголовка X-Next-Cursor; тело запроса повторяется",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/task.Task"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Курсор следующей страницы"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/search": {
+            "get": {
+                "description": "Ищет задачи по словам в заголовке и описании на русском и английском, начиная с лучших совпадений. Заголовок и фрагмент описания отдаются как HTML: текст экранирован, совпавшие слова выделены тегами \u003cb\u003e.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Полнотекстовый поиск задач",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Поисковый запрос; поддерживаются кавычки, or и -слово",
+                        "name": "q",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Число результатов, до 100, по умолчанию 20",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -222,7 +611,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/task.Task"
+                                "$ref": "#/definitions/task.SearchResult"
                             }
                         }
                     }
@@ -246,8 +635,49 @@ const docTemplate = `{
                         "name": "status",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы, до 1000, по умолчанию 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы из заголовка X-Next-Cursor; тело запроса повторяется",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/task.Task"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Курсор следующей страницы"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/trash": {
+            "get": {
+                "description": "Получает список удаленных задач, начиная с удаленных последними",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Получить задачи из корзины",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -263,6 +693,56 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "task.BulkResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": ""
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 200
+                },
+                "task": {
+                    "$ref": "#/definitions/task.Task"
+                }
+            }
+        },
+        "task.BulkUpdateTask": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string",
+                    "example": "Обновленная дата 2023-09-21T12:00:00Z"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Обновленное Описание задачи 1"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "status": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Обновленная Задача 1"
+                },
+                "version": {
+                    "description": "Version plays the part of If-Match for this task, 0 skips the check.",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
         "task.CreateTask": {
             "type": "object",
             "properties": {
@@ -284,6 +764,85 @@ const docTemplate = `{
                 }
             }
         },
+        "task.DateRangeQuery": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "description": "Date picks the calendar day, week (Monday to Sunday) or month given by\nPeriod, day by default. From and To give an arbitrary range instead.",
+                    "type": "string",
+                    "example": "2023-09-21"
+                },
+                "from": {
+                    "type": "string",
+                    "example": "2023-09-01"
+                },
+                "period": {
+                    "type": "string",
+                    "example": "week"
+                },
+                "status": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "time_zone": {
+                    "description": "TimeZone is the IANA zone in which the calendar is read and times\nwithout an offset are taken, UTC by default.",
+                    "type": "string",
+                    "example": "Europe/Moscow"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2023-10-01T12:00"
+                }
+            }
+        },
+        "task.FieldChange": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "status"
+                },
+                "new": {
+                    "type": "string",
+                    "example": "true"
+                },
+                "old": {
+                    "type": "string",
+                    "example": "false"
+                }
+            }
+        },
+        "task.HistoryEntry": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string",
+                    "example": "ivanov"
+                },
+                "changed_at": {
+                    "type": "string",
+                    "example": "2023-09-20T08:30:00Z"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/task.FieldChange"
+                    }
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "op": {
+                    "type": "string",
+                    "example": "update"
+                },
+                "task_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
         "task.PartiallyUpdateTask": {
             "type": "object",
             "properties": {
@@ -309,13 +868,76 @@ const docTemplate = `{
                 }
             }
         },
+        "task.SearchResult": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2023-09-19T17:00:00Z"
+                },
+                "date": {
+                    "type": "string",
+                    "example": "2023-09-21T12:00:00Z"
+                },
+                "deleted_at": {
+                    "description": "DeletedAt is set while the task is in the trash.",
+                    "type": "string",
+                    "example": "2023-09-25T18:00:00Z"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Описание задачи 1"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "rank": {
+                    "type": "number",
+                    "example": 0.6079271
+                },
+                "snippet": {
+                    "type": "string",
+                    "example": "Описание \u003cb\u003eзадачи\u003c/b\u003e 1"
+                },
+                "status": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Задача 1"
+                },
+                "title_highlight": {
+                    "type": "string",
+                    "example": "\u003cb\u003eЗадача\u003c/b\u003e 1 \u0026amp; 2"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2023-09-20T08:30:00Z"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
         "task.Task": {
             "type": "object",
             "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2023-09-19T17:00:00Z"
+                },
                 "date": {
                     "type": "string",
                     "example": "2023-09-21T12:00:00Z"
                 },
+                "deleted_at": {
+                    "description": "DeletedAt is set while the task is in the trash.",
+                    "type": "string",
+                    "example": "2023-09-25T18:00:00Z"
+                },
                 "description": {
                     "type": "string",
                     "example": "Описание задачи 1"
@@ -331,6 +953,14 @@ const docTemplate = `{
                 "title": {
                     "type": "string",
                     "example": "Задача 1"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2023-09-20T08:30:00Z"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 1
                 }
             }
         }